@@ -0,0 +1,269 @@
+// Command rnxedit applies a rules file of header field overrides to RINEX 3
+// observation files, such as to correct the MARKER NAME, receiver, antenna
+// and antenna height from station logs. Only the edited header records are
+// changed, with the rest of the header and the data section copied through
+// unchanged. Compressed input files are written uncompressed.
+//
+// The rules file is a JSON array of rules, or a YAML sequence of them for
+// files with a .yaml or .yml extension, each applying to the files of a
+// station (a four or nine character ID) whose TIME OF FIRST OBS is within
+// the optional from and until times. Rules with unknown fields are rejected
+// in either format:
+//
+//	[
+//	  {
+//	    "station": "ALBY00AUS",
+//	    "from": "2018-11-20T00:00:00Z",
+//	    "marker_name": "ALBY00AUS",
+//	    "marker_number": "50143M001",
+//	    "receiver_number": "3013512",
+//	    "receiver_type": "SEPT POLARX5",
+//	    "receiver_version": "5.2.0",
+//	    "antenna_number": "5117K80005",
+//	    "antenna_type": "JAVRINGANT_DM   SCIS",
+//	    "antenna_delta_hen": [0.0, 0.0, 0.0]
+//	  }
+//	]
+//
+// or in YAML:
+//
+//	# rules.yaml
+//	- station: ALBY00AUS
+//	  from: 2018-11-20T00:00:00Z
+//	  marker_name: ALBY00AUS
+//	  antenna_delta_hen: [0.0, 0.0, 0.0]
+//
+// Usage:
+//
+//	rnxedit -rules rules.json -o outdir [-dry-run] [-diff] files...
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex"
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/rinex3"
+	"gopkg.in/yaml.v2"
+)
+
+// Rule is an edit of the headers of a station's files, for files starting
+// from (inclusive) and until (exclusive) the given times if they are set.
+type Rule struct {
+	Station                      string     `json:"station" yaml:"station"`
+	From                         *time.Time `json:"from,omitempty" yaml:"from,omitempty"`
+	Until                        *time.Time `json:"until,omitempty" yaml:"until,omitempty"`
+	rinex3.ObservationHeaderEdit `yaml:",inline"`
+}
+
+// Matches returns true if the rule applies to a file of the station which
+// starts at the given time.
+func (r Rule) Matches(station string, start time.Time) bool {
+	ruleStation := strings.ToUpper(r.Station)
+	station = strings.ToUpper(station)
+	if len(ruleStation) == 9 && len(station) == 9 {
+		if ruleStation != station {
+			return false
+		}
+	} else if len(ruleStation) < 4 || len(station) < 4 || ruleStation[:4] != station[:4] {
+		return false
+	}
+	return (r.From == nil || !start.Before(*r.From)) && (r.Until == nil || start.Before(*r.Until))
+}
+
+func main() {
+	rulesPath := flag.String("rules", "", "JSON or YAML rules file of header field overrides")
+	outputDir := flag.String("o", "", "directory to write edited files to")
+	dryRun := flag.Bool("dry-run", false, "report the changes without writing files")
+	diff := flag.Bool("diff", false, "print the changed header records of each file")
+	flag.Parse()
+
+	if *rulesPath == "" || (*outputDir == "" && !*dryRun) || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: rnxedit -rules rules.json -o outdir [-dry-run] [-diff] files...")
+		os.Exit(2)
+	}
+
+	rules, err := readRules(*rulesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read rules: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		if err := editFile(path, rules, *outputDir, *dryRun, *diff); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func readRules(path string) (rules []Rule, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return rules, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(data, &rules)
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&rules)
+	}
+	if err != nil {
+		return rules, err
+	}
+	for i, rule := range rules {
+		if len(rule.Station) < 4 {
+			return rules, fmt.Errorf("rule %d has an invalid station \"%s\"", i+1, rule.Station)
+		}
+	}
+	return rules, nil
+}
+
+func editFile(path string, rules []Rule, outputDir string, dryRun, diff bool) error {
+	file, err := rinex.OpenRinexFileMode(path, header.Lenient)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	obsHeader, ok := file.Header.(rinex3.ObservationHeader)
+	if !ok {
+		return fmt.Errorf("only RINEX 3 observation files can be edited")
+	}
+	original := obsHeader.Records
+
+	// Files are matched by the station of their filename, falling back to the
+	// MARKER NAME, as the MARKER NAME may be the field being corrected
+	station := obsHeader.Marker.Name
+	filename, filenameErr := rinex.ParseRinexFilename(filepath.Base(path))
+	if filenameErr == nil {
+		station = filename.StationName
+	}
+
+	var changed []string
+	for _, rule := range rules {
+		if rule.Matches(station, obsHeader.TimeOfFirstObs.GoTime()) {
+			changed = append(changed, rule.Apply(&obsHeader)...)
+		}
+	}
+
+	outputPath := filepath.Join(outputDir, outputName(path, filename, filenameErr))
+	if len(changed) == 0 {
+		fmt.Printf("%s: unchanged\n", path)
+	} else {
+		fmt.Printf("%s: changed %s\n", path, strings.Join(unique(changed), ", "))
+	}
+	if diff && len(changed) > 0 {
+		fmt.Print(diffRecords(path, outputPath, original, obsHeader.Records))
+	}
+	if dryRun {
+		return nil
+	}
+	if same, _ := sameFile(path, outputPath); same {
+		return fmt.Errorf("output would overwrite the input file")
+	}
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	if _, err = io.WriteString(out, header.FormatHeaderRecords(obsHeader.Records)); err == nil {
+		_, err = io.Copy(out, file.Data())
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// outputName returns the name of an edited file, which is written as
+// uncompressed RINEX
+func outputName(path string, filename rinex.Filename, filenameErr error) string {
+	name := filepath.Base(path)
+	if filenameErr != nil {
+		return name
+	}
+
+	filename.Compression, filename.FileFormat = "", "rnx"
+	if filename.LongName {
+		return filename.String()
+	}
+	if shortName, err := filename.ShortName(); err == nil {
+		return shortName
+	}
+	return name
+}
+
+// diffRecords formats the records which differ between two headers, where
+// unchanged records are those with the same Line, as lines prefixed with "-"
+// and "+" following a "@@ -line +line @@" hunk header.
+func diffRecords(oldName, newName string, old, new []header.HeaderRecord) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		if i < len(old) && j < len(new) && old[i].Line == new[j].Line {
+			i, j = i+1, j+1
+			continue
+		}
+
+		var removed, added []string
+		oldStart, newStart := i+1, j+1
+		for j < len(new) && (new[j].Line == 0 || i == len(old)) {
+			added = append(added, new[j].String())
+			j++
+		}
+		for i < len(old) && (j == len(new) || old[i].Line != new[j].Line) {
+			removed = append(removed, old[i].String())
+			i++
+		}
+
+		fmt.Fprintf(&b, "@@ -%d +%d @@\n", oldStart, newStart)
+		for _, line := range removed {
+			fmt.Fprintf(&b, "-%s\n", line)
+		}
+		for _, line := range added {
+			fmt.Fprintf(&b, "+%s\n", line)
+		}
+	}
+	return b.String()
+}
+
+func sameFile(a, b string) (bool, error) {
+	aInfo, err := os.Stat(a)
+	if err != nil {
+		return false, err
+	}
+	bInfo, err := os.Stat(b)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(aInfo, bInfo), nil
+}
+
+func unique(keys []string) (result []string) {
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			result = append(result, key)
+		}
+	}
+	return result
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-gnss/rinex"
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/rinex3"
+)
+
+const fixture = "../../fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx"
+
+func TestRuleMatches(t *testing.T) {
+	from := time.Date(2018, time.November, 20, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2018, time.December, 1, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2018, time.November, 24, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		rule     Rule
+		station  string
+		start    time.Time
+		expected bool
+	}{
+		{Rule{Station: "ALBY00AUS"}, "ALBY00AUS", start, true},
+		{Rule{Station: "alby00aus"}, "ALBY00AUS", start, true},
+		{Rule{Station: "ALBY01AUS"}, "ALBY00AUS", start, false}, // Both nine characters
+		{Rule{Station: "ALBY"}, "ALBY00AUS", start, true},
+		{Rule{Station: "ALBY00AUS"}, "alby", start, true},
+		{Rule{Station: "HOB2"}, "ALBY00AUS", start, false},
+		{Rule{Station: "ALBY", From: &from}, "ALBY", from, true},
+		{Rule{Station: "ALBY", From: &from}, "ALBY", from.Add(-time.Second), false},
+		{Rule{Station: "ALBY", Until: &until}, "ALBY", until.Add(-time.Second), true},
+		{Rule{Station: "ALBY", Until: &until}, "ALBY", until, false},
+		{Rule{Station: "ALBY", From: &from, Until: &until}, "ALBY", start, true},
+	}
+	for _, test := range tests {
+		if matches := test.rule.Matches(test.station, test.start); matches != test.expected {
+			t.Errorf("%s (%v - %v) matching %s at %s: %t", test.rule.Station, test.rule.From, test.rule.Until, test.station, test.start, matches)
+		}
+	}
+}
+
+func TestReadRules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rnxedit")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"rules.json": `[{"station": "ALBY00AUS", "from": "2018-11-20T00:00:00Z", "marker_name": "ALBY",
+			"antenna_type": "JAVRINGANT_DM   SCIS", "antenna_delta_hen": [1.5, 0, 0]}]`,
+		"rules.yaml": "- station: ALBY00AUS\n  from: 2018-11-20T00:00:00Z\n  marker_name: ALBY\n" +
+			"  antenna_type: JAVRINGANT_DM   SCIS\n  antenna_delta_hen: [1.5, 0, 0]\n",
+		"invalid.yml":  "- station: ALB\n",
+		"unknown.yaml": "- station: ALBY\n  marker: ALBY\n",
+		"unknown.json": `[{"station": "ALBY", "marker": "ALBY"}]`,
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	from := time.Date(2018, time.November, 20, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"rules.json", "rules.yaml"} {
+		rules, err := readRules(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(rules) != 1 || rules[0].Station != "ALBY00AUS" || rules[0].From == nil || !rules[0].From.Equal(from) || rules[0].Until != nil {
+			t.Errorf("%s: incorrect rules: %+v", name, rules)
+			continue
+		}
+		edit := rules[0].ObservationHeaderEdit
+		if *edit.MarkerName != "ALBY" || *edit.AntennaType != "JAVRINGANT_DM   SCIS" || *edit.AntennaDelta != [3]float64{1.5, 0, 0} || edit.MarkerNumber != nil {
+			t.Errorf("%s: incorrect edit: %+v", name, edit)
+		}
+	}
+
+	for _, name := range []string{"invalid.yml", "unknown.yaml", "unknown.json", "missing.json"} {
+		if _, err := readRules(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	for path, expected := range map[string]string{
+		"in/ALBY00AUS_R_20183280000_01D_30S_MO.crx.gz": "ALBY00AUS_R_20183280000_01D_30S_MO.rnx",
+		"in/ALBY00AUS_R_20183280000_01D_30S_MO.rnx":    "ALBY00AUS_R_20183280000_01D_30S_MO.rnx",
+		"in/alby3280.18d.Z":                            "alby3280.18o",
+		"in/alby3280.18o":                              "alby3280.18o",
+		"in/observations.txt":                          "observations.txt",
+	} {
+		filename, err := rinex.ParseRinexFilename(filepath.Base(path))
+		if name := outputName(path, filename, err); name != expected {
+			t.Errorf("incorrect output name of %s: %s", path, name)
+		}
+	}
+}
+
+func TestDiffRecords(t *testing.T) {
+	old := []header.HeaderRecord{
+		{Value: "     3.03           OBSERVATION DATA    M", Key: "RINEX VERSION / TYPE", Line: 1},
+		{Value: "ALBY00AUS", Key: "MARKER NAME", Line: 2},
+		{Value: "50143M001", Key: "MARKER NUMBER", Line: 3},
+		{Value: "", Key: "END OF HEADER", Line: 4},
+	}
+	new := []header.HeaderRecord{
+		old[0],
+		{Value: "ALBY", Key: "MARKER NAME"},
+		old[2],
+		{Value: "        1.5000        0.0000        0.0000", Key: "ANTENNA: DELTA H/E/N"},
+		old[3],
+	}
+
+	expected := "--- old.rnx\n+++ new.rnx\n" +
+		"@@ -2 +2 @@\n" +
+		"-" + old[1].String() + "\n" +
+		"+" + new[1].String() + "\n" +
+		"@@ -4 +4 @@\n" +
+		"+" + new[3].String() + "\n"
+	if diff := diffRecords("old.rnx", "new.rnx", old, new); diff != expected {
+		t.Errorf("incorrect diff:\n%s\nexpected:\n%s", diff, expected)
+	}
+	if diff := diffRecords("old.rnx", "new.rnx", old, old); diff != "--- old.rnx\n+++ new.rnx\n" {
+		t.Errorf("incorrect diff of unchanged records:\n%s", diff)
+	}
+}
+
+// captureOutput returns what f writes to stdout
+func captureOutput(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	output := make(chan string)
+	go func() {
+		var buf bytes.Buffer
+		io.Copy(&buf, r)
+		output <- buf.String()
+	}()
+	f()
+	w.Close()
+	return <-output
+}
+
+func TestEditFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rnxedit")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	name := "ROVER"
+	rules := []Rule{
+		{Station: "ALBY", ObservationHeaderEdit: rinex3.ObservationHeaderEdit{MarkerName: &name}},
+		{Station: "HOB2", ObservationHeaderEdit: rinex3.ObservationHeaderEdit{MarkerNumber: &name}},
+	}
+
+	// A dry run reports the changes without writing anything
+	var editErr error
+	output := captureOutput(t, func() { editErr = editFile(fixture, rules, dir, true, true) })
+	if editErr != nil {
+		t.Fatal(editErr.Error())
+	}
+	if !strings.Contains(output, fixture+": changed MARKER NAME\n") || !strings.Contains(output, "+ROVER") || !strings.Contains(output, "-ALBY00AUS") {
+		t.Errorf("incorrect dry run output:\n%s", output)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("files were written by a dry run: %v", files)
+	}
+
+	output = captureOutput(t, func() { editErr = editFile(fixture, rules, dir, false, false) })
+	if editErr != nil {
+		t.Fatal(editErr.Error())
+	}
+	if output != fixture+": changed MARKER NAME\n" {
+		t.Errorf("incorrect output:\n%s", output)
+	}
+
+	original, err := ioutil.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	edited, err := ioutil.ReadFile(filepath.Join(dir, filepath.Base(fixture)))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := strings.Replace(string(original), "ALBY00AUS                                                   MARKER NAME",
+		"ROVER                                                       MARKER NAME", 1)
+	if string(edited) != expected {
+		t.Errorf("incorrect edited file:\n%s", edited)
+	}
+
+	// Files which aren't RINEX 3 observation files are rejected
+	captureOutput(t, func() { editErr = editFile("../../fixtures/alby3280.18o", rules, dir, true, false) })
+	if editErr == nil {
+		t.Error("expected an error editing a RINEX 2 file")
+	}
+}
@@ -0,0 +1,102 @@
+// Package compression decompresses the formats used for distributing RINEX
+// files, being gzip (.gz), bzip2 (.bz2), zip (.zip) and Unix compress (.Z).
+package compression
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Compression methods, named after their filename extensions
+const (
+	None  = ""
+	Gzip  = "gz"
+	Bzip2 = "bz2"
+	Zip   = "zip"
+	LZW   = "Z" // Unix compress
+)
+
+var magicNumbers = map[string][]byte{
+	Gzip:  {0x1f, 0x8b},
+	Bzip2: []byte("BZh"),
+	Zip:   []byte("PK\x03\x04"),
+	LZW:   {0x1f, 0x9d},
+}
+
+// Method returns the compression method for a filename extension (without
+// the leading "."), which is case insensitive apart from "Z" and "z" both
+// being Unix compress
+func Method(extension string) (string, error) {
+	if extension == "Z" || extension == "z" {
+		return LZW, nil
+	}
+	switch strings.ToLower(extension) {
+	case "":
+		return None, nil
+	case "gz", "gzip":
+		return Gzip, nil
+	case "bz2":
+		return Bzip2, nil
+	case "zip":
+		return Zip, nil
+	default:
+		return None, fmt.Errorf("unsupported compression \"%s\"", extension)
+	}
+}
+
+// Detect returns the compression method of the data in r from its magic
+// number, without consuming any of it
+func Detect(r *bufio.Reader) string {
+	magic, _ := r.Peek(4)
+	for method, number := range magicNumbers {
+		if bytes.HasPrefix(magic, number) {
+			return method
+		}
+	}
+	return None
+}
+
+// NewReader returns a reader which decompresses r with the given method, or
+// returns r as is for None. Zip archives must contain a single file, and are
+// read entirely into memory as the zip format requires random access.
+func NewReader(r io.Reader, method string) (io.Reader, error) {
+	switch method {
+	case None:
+		return r, nil
+	case Gzip:
+		return gzip.NewReader(r)
+	case Bzip2:
+		return bzip2.NewReader(r), nil
+	case LZW:
+		return NewLZWReader(r)
+	case Zip:
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		var files []*zip.File
+		for _, file := range archive.File {
+			if !file.FileInfo().IsDir() {
+				files = append(files, file)
+			}
+		}
+		if len(files) != 1 {
+			return nil, errors.New("zip archive must contain exactly one file")
+		}
+		return files[0].Open()
+	default:
+		return nil, fmt.Errorf("unsupported compression method \"%s\"", method)
+	}
+}
@@ -0,0 +1,77 @@
+package compression_test
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/go-gnss/rinex/compression"
+)
+
+func TestLZWReader(t *testing.T) {
+	want, err := ioutil.ReadFile("../fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	file, err := os.Open("../fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx.Z")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	defer file.Close()
+
+	reader, err := compression.NewLZWReader(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	got, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("incorrect decompressed data:\n%s", got)
+	}
+}
+
+func TestLZWReaderInvalidHeader(t *testing.T) {
+	if _, err := compression.NewLZWReader(bytes.NewReader([]byte{0x1f, 0x8b, 0x08})); err == nil {
+		t.Error("expected error for gzip magic number")
+	}
+	if _, err := compression.NewLZWReader(bytes.NewReader([]byte{0x1f, 0x9d, 0x80 | 17})); err == nil {
+		t.Error("expected error for maximum code width over 16 bits")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for path, method := range map[string]string{
+		"../fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx":     compression.None,
+		"../fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx.Z":   compression.LZW,
+		"../fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.crx.gz":  compression.Gzip,
+		"../fixtures/ALBY00AUS_R_20183280000_01D_MN.rnx.bz2":     compression.Bzip2,
+		"../fixtures/ALBY00AUS_R_20183280000_01D_05M_MM.rnx.zip": compression.Zip,
+	} {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if got := compression.Detect(bufio.NewReader(file)); got != method {
+			t.Errorf("%s: incorrect compression method \"%s\"", path, got)
+		}
+		file.Close()
+	}
+}
+
+func TestMethod(t *testing.T) {
+	for extension, method := range map[string]string{
+		"gz": compression.Gzip, "GZ": compression.Gzip, "Z": compression.LZW, "z": compression.LZW,
+		"bz2": compression.Bzip2, "zip": compression.Zip, "": compression.None,
+	} {
+		if got, err := compression.Method(extension); err != nil || got != method {
+			t.Errorf("%s: incorrect compression method \"%s\" (%v)", extension, got, err)
+		}
+	}
+	if _, err := compression.Method("xz"); err == nil {
+		t.Error("expected error for unsupported compression")
+	}
+}
@@ -0,0 +1,187 @@
+package compression
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	lzwInitialBits = 9
+	lzwClear       = 256
+	lzwBlockMode   = 0x80
+	lzwMaxBitsMask = 0x1f
+)
+
+// LZWReader decompresses data produced by the Unix compress utility (.Z),
+// which differs from the LZW variant read by compress/lzw in its header,
+// CLEAR code and in codes being written in groups of eight - when the code
+// width changes, the rest of the current group is padding which is skipped.
+type LZWReader struct {
+	r         *bufio.Reader
+	maxBits   uint
+	blockMode bool
+
+	bits     uint64 // Bit buffer, least significant bit first
+	numBits  uint
+	consumed uint // Bits read since the code width last changed
+	width    uint
+
+	prefix   []uint16
+	suffix   []byte
+	next     int // Next free code
+	previous int
+	first    byte
+	stack    []byte
+
+	out bytes.Buffer
+	err error
+}
+
+// NewLZWReader returns a reader which decompresses the Unix compress data in r.
+func NewLZWReader(r io.Reader) (*LZWReader, error) {
+	reader := &LZWReader{r: bufio.NewReader(r)}
+
+	header := make([]byte, 3)
+	if _, err := io.ReadFull(reader.r, header); err != nil {
+		return nil, fmt.Errorf("failed to read compress header: %v", err)
+	}
+	if !bytes.Equal(header[:2], magicNumbers[LZW]) {
+		return nil, errors.New("invalid compress magic number")
+	}
+
+	reader.maxBits = uint(header[2] & lzwMaxBitsMask)
+	reader.blockMode = header[2]&lzwBlockMode != 0
+	if reader.maxBits < lzwInitialBits || reader.maxBits > 16 {
+		return nil, fmt.Errorf("invalid compress maximum code width %d", reader.maxBits)
+	}
+
+	reader.prefix = make([]uint16, 1<<reader.maxBits)
+	reader.suffix = make([]byte, 1<<reader.maxBits)
+	for i := 0; i < 256; i++ {
+		reader.suffix[i] = byte(i)
+	}
+	reader.reset()
+	return reader, nil
+}
+
+func (z *LZWReader) reset() {
+	z.width = lzwInitialBits
+	z.consumed = 0
+	z.previous = -1
+	z.next = 256
+	if z.blockMode {
+		z.next = lzwClear + 1
+	}
+}
+
+// align skips the padding at the end of the current group of codes, which is
+// a multiple of the code width in bytes from where the width last changed
+func (z *LZWReader) align() error {
+	groupBits := z.width * 8
+	if padding := (groupBits - z.consumed%groupBits) % groupBits; padding > 0 {
+		if _, err := z.readBits(padding); err != nil {
+			return err
+		}
+	}
+	z.consumed = 0
+	return nil
+}
+
+func (z *LZWReader) readBits(n uint) (uint64, error) {
+	for z.numBits < n {
+		b, err := z.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		z.bits |= uint64(b) << z.numBits
+		z.numBits += 8
+	}
+	value := z.bits & (1<<n - 1)
+	z.bits >>= n
+	z.numBits -= n
+	z.consumed += n
+	return value, nil
+}
+
+func (z *LZWReader) Read(p []byte) (int, error) {
+	for z.out.Len() < len(p) && z.err == nil {
+		z.err = z.decode()
+	}
+	if z.out.Len() > 0 {
+		return z.out.Read(p)
+	}
+	return 0, z.err
+}
+
+// decode reads and decodes the next code into the output buffer
+func (z *LZWReader) decode() error {
+	if z.next >= 1<<z.width && z.width < z.maxBits {
+		if err := z.align(); err != nil {
+			return eof(err)
+		}
+		z.width++
+	}
+
+	value, err := z.readBits(z.width)
+	if err != nil {
+		return eof(err)
+	}
+	code := int(value)
+
+	if z.previous == -1 {
+		if code > 255 {
+			return errors.New("corrupt compress data")
+		}
+		z.previous, z.first = code, byte(code)
+		z.out.WriteByte(z.first)
+		return nil
+	}
+
+	if code == lzwClear && z.blockMode {
+		if err := z.align(); err != nil {
+			return eof(err)
+		}
+		z.reset()
+		return nil
+	}
+
+	incoming := code
+	z.stack = z.stack[:0]
+	if code >= z.next { // The code being defined, for KwKwK strings
+		if code > z.next {
+			return errors.New("corrupt compress data")
+		}
+		z.stack = append(z.stack, z.first)
+		code = z.previous
+	}
+	for code >= 256 {
+		z.stack = append(z.stack, z.suffix[code])
+		code = int(z.prefix[code])
+	}
+	z.first = z.suffix[code]
+	z.stack = append(z.stack, z.first)
+
+	for i := len(z.stack) - 1; i >= 0; i-- {
+		z.out.WriteByte(z.stack[i])
+	}
+
+	if z.next < 1<<z.maxBits {
+		z.prefix[z.next] = uint16(z.previous)
+		z.suffix[z.next] = z.first
+		z.next++
+	}
+	z.previous = incoming
+	return nil
+}
+
+// eof treats running out of data part way through a code as the end of the
+// stream, as the final group of codes is padded to a whole number of bytes
+func eof(err error) error {
+	if err == io.ErrUnexpectedEOF {
+		return io.EOF
+	}
+	return err
+}
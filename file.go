@@ -5,65 +5,292 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 
+	"github.com/go-gnss/rinex/compression"
+	"github.com/go-gnss/rinex/hatanaka"
 	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/rinex2"
 	"github.com/go-gnss/rinex/rinex3"
+	"github.com/go-gnss/rinex/rinex4"
 	"github.com/go-gnss/rinex/scanner"
 )
 
-// TODO: Implement differentiation between RINEX 2 and 3
 // TODO: Implement RinexFileName
 
 type RinexHeader interface {
 	GetFormatVersion() float64
 	GetFileType() string // TODO: FileType type
+	GetRecords() []header.HeaderRecord
 }
 
 type RinexFile struct {
 	scanner *scanner.Scanner
+	closer  io.Closer
 	Header  RinexHeader
+	// CurrentHeader is the header in effect at the last epoch read by
+	// NextEpoch, being Header with the header records of any special events
+	// applied, such as a new antenna or marker
+	CurrentHeader RinexHeader
+	occupations   []rinex3.Occupation
 }
 
 // TODO: Header gives RinexVersion and FileType, consider implementation
 // of Rinex3ObservationFile, Rinex2NavigationFile, etc
 
-// TODO: Can scan through observations like so, or parse them into a map
-//func (r RinexFile) NextEpoch() (epoch EpochRecord, err error) {
-//	return epoch, err
-//}
-
+// ParseRinexFile parses the header of a RINEX file, leaving the data section
+// to be read one record at a time with NextEpoch, NextNavigationRecord,
+// NextMeteorologicalRecord or NextClockRecord.
+//
+// Compact RINEX (Hatanaka compressed) files are detected by their CRINEX
+// VERS / TYPE line and decompressed transparently.
 func ParseRinexFile(data io.Reader) (file RinexFile, err error) {
-	scanner := &scanner.Scanner{bufio.NewReader(data), 0}
-	header, err := ParseHeader(scanner)
+	return ParseRinexFileMode(data, header.Strict)
+}
+
+// ParseRinexFileMode is ParseRinexFile with the header.ParseMode used for
+// RINEX 3 observation headers, where header.Lenient collects violations of
+// the header format in the ObservationHeader's Warnings rather than failing.
+func ParseRinexFileMode(data io.Reader, mode header.ParseMode) (file RinexFile, err error) {
+	reader := bufio.NewReader(data)
+	if hatanaka.IsCompact(reader) {
+		decompressor, err := hatanaka.NewReader(reader)
+		if err != nil {
+			return file, err
+		}
+		reader = bufio.NewReader(decompressor)
+	}
+
+	scanner := &scanner.Scanner{Reader: reader}
+	rinexHeader, err := ParseHeaderMode(scanner, mode)
 	file = RinexFile{
 		scanner: scanner,
-		Header:  header,
+		Header:  rinexHeader,
 	}
+	return file, err
+}
+
+// OpenRinexFile opens and parses the header of the RINEX file at path, which
+// may be compressed with gzip, bzip2, zip or Unix compress. The compression
+// method is taken from the Compression suffix of the filename, falling back
+// to the magic number at the start of the file for filenames which don't
+// follow the RINEX naming convention. The file must be closed with Close.
+func OpenRinexFile(path string) (file RinexFile, err error) {
+	return OpenRinexFileMode(path, header.Strict)
+}
+
+// OpenRinexFileMode is OpenRinexFile with the header.ParseMode used for
+// RINEX 3 observation headers.
+func OpenRinexFileMode(path string, mode header.ParseMode) (file RinexFile, err error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return file, err
 	}
 
-	for err == nil {
-		//var epoch rinex3.EpochRecord
-		_, err = rinex3.ParseEpochRecord(scanner, file.Header.(rinex3.ObservationHeader).ObservationTypes)
-		//fmt.Println(epoch, err)
+	reader := bufio.NewReader(f)
+	method := compression.Detect(reader)
+	if filename, err := ParseRinexFilename(filepath.Base(path)); err == nil && filename.Compression != "" {
+		if method, err = compression.Method(filename.Compression); err != nil {
+			f.Close()
+			return file, err
+		}
 	}
-	if err != io.EOF {
-		return file, err
+
+	decompressor, err := compression.NewReader(reader, method)
+	if err != nil {
+		f.Close()
+		return file, fmt.Errorf("failed to decompress %s: %v", path, err)
+	}
+
+	file, err = ParseRinexFileMode(decompressor, mode)
+	file.closer = f
+	if err != nil {
+		f.Close()
+	}
+	return file, err
+}
+
+// Close closes the underlying file of a RinexFile opened with OpenRinexFile.
+func (r *RinexFile) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
+
+// Data returns a reader of the remainder of the data section which hasn't
+// been parsed, such as for copying it unchanged after editing the header.
+// Compressed and Hatanaka compressed data is decompressed.
+func (r *RinexFile) Data() io.Reader {
+	return r.scanner.Reader
+}
+
+// NextEpoch parses the next EpochRecord from the data section of the file,
+// returning io.EOF once there are no more epochs. Only a single epoch is held
+// in memory at a time, so arbitrarily large files can be streamed.
+//
+// The header records of special events are applied to CurrentHeader, so
+// that later epochs are parsed with any new observation types, and the
+// epochs are split into the Occupations of the file.
+func (r *RinexFile) NextEpoch() (epoch rinex3.EpochRecord, err error) {
+	if r.CurrentHeader == nil {
+		r.CurrentHeader = r.Header
+	}
+
+	var marker string
+	switch h := r.CurrentHeader.(type) {
+	case rinex3.ObservationHeader:
+		epoch, err = rinex3.ParseEpochRecord(r.scanner, h.ObservationTypes, h.TimeSystem())
+		if err == nil && len(epoch.HeaderRecords) > 0 {
+			h, err = h.WithRecords(epoch.HeaderRecords)
+			r.CurrentHeader = h
+		}
+		marker = h.Marker.Name
+	case rinex2.ObservationHeader:
+		epoch, err = rinex2.ParseEpochRecord(r.scanner, h.ObservationTypes, h.TimeSystem())
+		if err == nil && len(epoch.HeaderRecords) > 0 {
+			h, err = h.WithRecords(epoch.HeaderRecords)
+			r.CurrentHeader = h
+		}
+		marker = h.Marker.Name
+	default:
+		return epoch, errors.New("epochs can only be read from observation files")
+	}
+
+	if err == nil {
+		r.occupations = rinex3.UpdateOccupations(r.occupations, epoch, marker)
+	}
+	return epoch, err
+}
+
+// Occupations returns the occupations of the epochs read so far, being the
+// segments of the file at each marker, or while moving
+func (r *RinexFile) Occupations() []rinex3.Occupation {
+	return r.occupations
+}
+
+// Epochs reads all remaining epochs into memory, which is only suitable for
+// small files - use NextEpoch for anything else.
+func (r *RinexFile) Epochs() (epochs []rinex3.EpochRecord, err error) {
+	for {
+		epoch, err := r.NextEpoch()
+		if err == io.EOF {
+			return epochs, nil
+		}
+		if err != nil {
+			return epochs, err
+		}
+		epochs = append(epochs, epoch)
+	}
+}
+
+// NextNavigationRecord parses the next record from the data section of a
+// navigation file, returning io.EOF once there are no more. RINEX 3 files
+// contain only ephemerides, while RINEX 4 files also contain system time
+// offset, Earth orientation and ionosphere records (see the rinex4 package).
+func (r *RinexFile) NextNavigationRecord() (record rinex3.NavigationRecord, err error) {
+	switch r.Header.(type) {
+	case rinex3.NavigationHeader:
+		return rinex3.ParseNavigationRecordVersion(r.scanner, r.Header.GetFormatVersion())
+	case rinex4.NavigationHeader:
+		return rinex4.ParseNavigationRecord(r.scanner)
+	default:
+		return record, errors.New("navigation records can only be read from navigation files")
+	}
+}
+
+// NavigationRecords reads all remaining navigation records into memory.
+func (r *RinexFile) NavigationRecords() (records []rinex3.NavigationRecord, err error) {
+	for {
+		record, err := r.NextNavigationRecord()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// NextMeteorologicalRecord parses the next record from the data section of a
+// RINEX 2 or 3 meteorological file, returning io.EOF once there are no more.
+func (r *RinexFile) NextMeteorologicalRecord() (record rinex3.MeteorologicalRecord, err error) {
+	metHeader, ok := r.Header.(rinex3.MeteorologicalHeader)
+	if !ok {
+		return record, errors.New("meteorological records can only be read from meteorological files")
+	}
+	return rinex3.ParseMeteorologicalRecord(r.scanner, metHeader.FormatVersion, metHeader.ObservationTypes)
+}
+
+// MeteorologicalRecords reads all remaining meteorological records into memory.
+func (r *RinexFile) MeteorologicalRecords() (records []rinex3.MeteorologicalRecord, err error) {
+	for {
+		record, err := r.NextMeteorologicalRecord()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
+
+// NextClockRecord parses the next record from the data section of a clock
+// file, returning io.EOF once there are no more.
+func (r *RinexFile) NextClockRecord() (record rinex3.ClockRecord, err error) {
+	if _, ok := r.Header.(rinex3.ClockHeader); !ok {
+		return record, errors.New("clock records can only be read from clock files")
+	}
+	return rinex3.ParseClockRecord(r.scanner)
+}
+
+// ClockRecords reads all remaining clock records into memory, which can be
+// passed to rinex3.NewSatelliteClocks for interpolating satellite clocks.
+func (r *RinexFile) ClockRecords() (records []rinex3.ClockRecord, err error) {
+	for {
+		record, err := r.NextClockRecord()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
 	}
-	return file, nil
 }
 
 // TODO: Check for empty strings / missing required values?
+
+// ParseHeader parses the header of a RINEX file in header.Strict mode.
 func ParseHeader(scanner *scanner.Scanner) (rinexHeader RinexHeader, err error) {
-	hr, err := header.ParseHeaderRecord(scanner)
+	return ParseHeaderMode(scanner, header.Strict)
+}
+
+// ParseHeaderMode parses the header of a RINEX file, with the mode used for
+// RINEX 3 observation headers. The raw lines of the header are kept in its
+// Records.
+func ParseHeaderMode(scanner *scanner.Scanner, mode header.ParseMode) (rinexHeader RinexHeader, err error) {
+	scanner.Record, scanner.Recorded = true, nil
+	defer func() { scanner.Record, scanner.Recorded = false, nil }()
+	records := func() []header.HeaderRecord {
+		return header.NewHeaderRecords(scanner.Recorded)
+	}
+
+	line, err := scanner.ReadLine()
+	if err != nil {
+		return rinexHeader, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+	column := header.DetectLabelColumn(line)
+	hr, err := header.NewHeaderRecordAt(line, scanner.Line, column)
 	if err != nil {
 		return rinexHeader, header.NewHeaderRecordParsingError(err, scanner.Line)
 	}
 
-	// TODO: This isn't true for CRX files, but that is not reflected in the format
-	// description - though it does mention .crx extensions are allowed in the
-	// filename
+	// CRX files start with CRINEX VERS / TYPE, but ParseRinexFile decompresses
+	// them before the header is parsed
 	if hr.Key != "RINEX VERSION / TYPE" {
 		return rinexHeader, errors.New("first line of header must be \"RINEX VERSION / TYPE\"")
 	}
@@ -73,14 +300,42 @@ func ParseHeader(scanner *scanner.Scanner) (rinexHeader RinexHeader, err error)
 	if err != nil {
 		return rinexHeader, header.NewHeaderRecordParsingError(err, scanner.Line)
 	}
+	if column != h.LabelColumn() {
+		return rinexHeader, header.NewHeaderRecordParsingError(
+			errors.New("header labels must start at column 61, apart from clock files since version 3.04"), scanner.Line)
+	}
 
-	// TODO: NavigationHeader and MeteorologicalHeader
-	// TODO: RINEX 2 and 3
-	switch h.FileType {
-	case "O":
+	switch {
+	case h.FileType == "O" && h.FormatVersion < 3:
+		obsHeader := rinex2.NewObservationHeader(h)
+		err = rinex2.ParseObservationHeader(scanner, &obsHeader)
+		obsHeader.Records = records()
+		return obsHeader, err
+	case h.FileType == "O":
 		obsHeader := rinex3.NewObservationHeader(h)
-		err = rinex3.ParseObservationHeader(scanner, &obsHeader)
+		err = rinex3.ParseObservationHeaderMode(scanner, &obsHeader, mode)
+		obsHeader.Records = records()
 		return obsHeader, err
+	case h.FileType == "N" && h.FormatVersion >= 4:
+		navHeader := rinex4.NewNavigationHeader(h)
+		err = rinex4.ParseNavigationHeader(scanner, &navHeader)
+		navHeader.Records = records()
+		return navHeader, err
+	case h.FileType == "N" && h.FormatVersion >= 3:
+		navHeader := rinex3.NewNavigationHeader(h)
+		err = rinex3.ParseNavigationHeader(scanner, &navHeader)
+		navHeader.Records = records()
+		return navHeader, err
+	case h.FileType == "M":
+		metHeader := rinex3.NewMeteorologicalHeader(h)
+		err = rinex3.ParseMeteorologicalHeader(scanner, &metHeader)
+		metHeader.Records = records()
+		return metHeader, err
+	case h.FileType == "C":
+		clkHeader := rinex3.NewClockHeader(h)
+		err = rinex3.ParseClockHeader(scanner, &clkHeader)
+		clkHeader.Records = records()
+		return clkHeader, err
 	default:
 		return rinexHeader, errors.New(fmt.Sprintf("invalid header type \"%v\"", h.FileType))
 	}
@@ -1,11 +1,25 @@
 package rinex_test
 
 import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-gnss/rinex"
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/gnsstime"
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/rinex2"
 	"github.com/go-gnss/rinex/rinex3"
+	"github.com/go-gnss/rinex/rinex4"
+	"github.com/go-gnss/rinex/scanner"
 )
 
 func TestParseObservationFile(t *testing.T) {
@@ -33,3 +47,1531 @@ func TestParseObservationFile(t *testing.T) {
 
 	// TODO: Test header attributes
 }
+
+func TestNextEpoch(t *testing.T) {
+	file, err := os.Open("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal("failed to open test observation file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	epoch, err := rinexFile.NextEpoch()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(epoch.ObservationRecords) != 4 {
+		t.Errorf("incorrect number of observation records: %d", len(epoch.ObservationRecords))
+	}
+	if obs := epoch.ObservationRecords[0].Observations[0].Value; obs != 22107568.420 {
+		t.Errorf("incorrect first observation value: %f", obs)
+	}
+
+	epochs, err := rinexFile.Epochs()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(epochs) != 2 {
+		t.Errorf("incorrect number of remaining epochs: %d", len(epochs))
+	}
+
+	if _, err := rinexFile.NextEpoch(); err != io.EOF {
+		t.Errorf("expected io.EOF after final epoch, got %v", err)
+	}
+}
+
+func TestParseObservationRecord(t *testing.T) {
+	codes := map[gnss.SatelliteSystem][]gnss.ObservationCode{gnss.GPS: {
+		{Type: gnss.Pseudorange, Band: 1, Attribute: 'C'},
+		{Type: gnss.Phase, Band: 1, Attribute: 'C'},
+		{Type: gnss.Doppler, Band: 1, Attribute: 'C'},
+		{Type: gnss.SignalStrength, Band: 1, Attribute: 'C'},
+	}}
+
+	// The line ends early, but every code still has an observation
+	record, err := rinex3.ParseObservationRecord("G05         0.000   116174032.45610", codes)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(record.Observations) != 4 {
+		t.Fatalf("incorrect number of observations: %+v", record.Observations)
+	}
+	for i, obs := range record.Observations {
+		if obs.Code != codes[gnss.GPS][i] {
+			t.Errorf("incorrect code of observation %d: %s", i, obs.Code)
+		}
+	}
+	if obs := record.Observations[0]; !obs.Valid || obs.Value != 0 || obs.HasLLI || obs.HasSSI {
+		t.Errorf("incorrect zero observation: %+v", obs)
+	}
+	if obs := record.Observations[1]; !obs.Valid || obs.LLI != 1 || !obs.HasLLI || obs.SignalStrength != 0 || !obs.HasSSI {
+		t.Errorf("incorrect phase observation: %+v", obs)
+	}
+	for _, obs := range record.Observations[2:] {
+		if obs != rinex3.MissingObservation(obs.Code) {
+			t.Errorf("observation should be missing: %+v", obs)
+		}
+	}
+}
+
+func TestObservationIndicators(t *testing.T) {
+	obs, err := rinex3.ParseObservation("  22107568.420 7")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if obs.HasLLI || obs.LLI.LossOfLock() || obs.SignalStrength != 7 || !obs.HasSSI {
+		t.Errorf("incorrect indicators: %+v", obs)
+	}
+	if min, max, ok := obs.SignalStrength.DBHz(); !ok || min != 42 || max != 48 {
+		t.Errorf("incorrect SSI range: %f-%f", min, max)
+	}
+
+	obs, err = rinex3.ParseObservation("  22107568.420  ")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if obs.HasLLI || obs.HasSSI || obs.LLI != 0 || obs.SignalStrength != 0 {
+		t.Errorf("incorrect blank indicators: %+v", obs)
+	}
+	if _, _, ok := obs.SignalStrength.DBHz(); ok {
+		t.Error("a blank SSI should have no range")
+	}
+
+	lli := rinex3.LLILossOfLock | rinex3.LLIBOCTracking
+	if !lli.LossOfLock() || lli.HalfCycleAmbiguity() || !lli.BOCTracking() || !lli.AntiSpoofing() {
+		t.Errorf("incorrect LLI flags: %d", lli)
+	}
+	if lli = rinex3.LLI(2); lli.LossOfLock() || !lli.HalfCycleAmbiguity() {
+		t.Errorf("incorrect LLI flags: %d", lli)
+	}
+
+	for dbhz, expected := range map[float64]rinex3.SSI{0: 1, 11.9: 1, 12: 2, 35.5: 5, 47.9: 7, 54: 9, 60: 9} {
+		ssi := rinex3.NewSSI(dbhz)
+		if ssi != expected {
+			t.Errorf("incorrect SSI for %f dBHz: %d", dbhz, ssi)
+		}
+		if min, max, _ := ssi.DBHz(); dbhz < min || dbhz >= max {
+			t.Errorf("%f dBHz is outside the range of SSI %d: %f-%f", dbhz, ssi, min, max)
+		}
+	}
+
+	h := rinex3.ObservationHeader{SignalStrength: "DBHZ"}
+	snr := rinex3.Observation{Code: gnss.ObservationCode{Type: gnss.SignalStrength, Band: 1, Attribute: 'C'}, Value: 45.25, Valid: true, SignalStrength: 7, HasSSI: true}
+	if min, max, ok := h.SignalStrengthDBHz(snr); !ok || min != 45.25 || max != 45.25 {
+		t.Errorf("incorrect signal strength of S observation: %f-%f", min, max)
+	}
+	h.SignalStrength = ""
+	if min, max, ok := h.SignalStrengthDBHz(snr); !ok || min != 42 || max != 48 {
+		t.Errorf("incorrect signal strength without a unit: %f-%f", min, max)
+	}
+
+	// The zero value of an indicator is blank, apart from a zero which the
+	// observation has
+	if field, err := rinex3.FormatObservation(snr); err != nil || field != "        45.250 7" {
+		t.Errorf("incorrect observation field %q (%v)", field, err)
+	}
+	snr.HasLLI = true
+	if field, err := rinex3.FormatObservation(snr); err != nil || field != "        45.25007" {
+		t.Errorf("incorrect observation field %q (%v)", field, err)
+	}
+	if field, err := rinex3.FormatObservation(rinex3.Observation{Value: 45.25, Valid: true}); err != nil || field != "        45.250  " {
+		t.Errorf("incorrect observation field %q (%v)", field, err)
+	}
+
+	// Indicators which don't fit in a single digit can't be written
+	for _, obs := range []rinex3.Observation{
+		{Value: 45.25, Valid: true, LLI: 12, HasLLI: true, SignalStrength: 7, HasSSI: true},
+		{Value: 45.25, Valid: true, SignalStrength: 10, HasSSI: true},
+		{Value: 45.25, Valid: true, LLI: -2, HasLLI: true},
+	} {
+		if field, err := rinex3.FormatObservation(obs); err == nil {
+			t.Errorf("expected an error formatting indicators %d and %d, got %q", obs.LLI, obs.SignalStrength, field)
+		}
+	}
+	epoch := rinex3.EpochRecord{ObservationRecords: []rinex3.ObservationRecord{{
+		Satellite:    gnss.SatelliteID{System: gnss.GPS, Number: 5},
+		Observations: []rinex3.Observation{{Code: snr.Code, Value: 45.25, Valid: true, LLI: 12, HasLLI: true}},
+	}}}
+	if _, err := rinex3.FormatEpochRecord(epoch, map[gnss.SatelliteSystem][]gnss.ObservationCode{gnss.GPS: {snr.Code}}); err == nil {
+		t.Error("expected an error formatting an epoch with an LLI of 12")
+	}
+}
+
+func TestParseHighRateEpochs(t *testing.T) {
+	codes := map[gnss.SatelliteSystem][]gnss.ObservationCode{gnss.GPS: {{Type: gnss.Pseudorange, Band: 1, Attribute: 'C'}}}
+	data := "> 2018 11 24 00 00 30.1000000  0  1\nG05  22107568.420\n" +
+		"> 2018 11 24 00 00 30.3000000  0  1\nG05  22107568.520\n"
+	s := &scanner.Scanner{Reader: bufio.NewReader(strings.NewReader(data))}
+
+	var previous gnss.Time
+	for i, expected := range []time.Duration{100 * time.Millisecond, 300 * time.Millisecond} {
+		epoch, err := rinex3.ParseEpochRecord(s, codes, gnss.GPSTime)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if epoch.Time.System() != gnss.GPSTime || epoch.Time.GoTime() != time.Date(2018, 11, 24, 0, 0, 30, 0, time.UTC).Add(expected) {
+			t.Errorf("incorrect epoch time: %s", epoch.Time)
+		}
+		if i > 0 && epoch.Time.Sub(previous) != 200*time.Millisecond {
+			t.Errorf("incorrect interval between epochs: %s", epoch.Time.Sub(previous))
+		}
+		previous = epoch.Time
+	}
+
+	// The fraction is written back in full
+	epoch := rinex3.EpochRecord{Time: previous}
+	if line, err := rinex3.FormatEpochRecord(epoch, codes); err != nil || line != "> 2018 11 24 00 00 30.3000000  0  0\n" {
+		t.Errorf("incorrect epoch line %q (%v)", line, err)
+	}
+}
+
+func TestObservationEvents(t *testing.T) {
+	file, err := os.Open("fixtures/ALBY00AUS_R_20183280000_05M_30S_MO.rnx")
+	if err != nil {
+		t.Fatal("failed to open test observation file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	epochs, err := rinexFile.Epochs()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(epochs) != 9 {
+		t.Fatalf("incorrect number of epochs: %d", len(epochs))
+	}
+
+	for i, expected := range []rinex3.EpochFlag{
+		rinex3.EpochOK, rinex3.EpochStartMoving, rinex3.EpochOK, rinex3.EpochNewOccupation,
+		rinex3.EpochOK, rinex3.EpochHeaderInformation, rinex3.EpochOK, rinex3.EpochExternalEvent, rinex3.EpochOK,
+	} {
+		if epochs[i].Flag != expected || epochs[i].Flag.IsEvent() != (expected != rinex3.EpochOK) {
+			t.Errorf("incorrect flag of epoch %d: %d", i, epochs[i].Flag)
+		}
+	}
+	if occupation := epochs[3]; !occupation.Time.IsZero() || len(occupation.HeaderRecords) != 2 ||
+		occupation.HeaderRecords[0].Key != "MARKER NAME" || strings.TrimSpace(occupation.HeaderRecords[0].Value) != "ROVER" {
+		t.Errorf("incorrect new occupation event: %+v", occupation)
+	}
+
+	// The new observation types are used from the header information event
+	if obs := epochs[6].ObservationRecords[0].Observations; len(obs) != 2 || obs[1].Code.String() != "L1C" || obs[1].Value != 116285143.486 {
+		t.Errorf("incorrect observations after the header information event: %+v", obs)
+	}
+
+	// The events are applied to the current header, but not the original
+	original := rinexFile.Header.(rinex3.ObservationHeader)
+	current := rinexFile.CurrentHeader.(rinex3.ObservationHeader)
+	if current.Marker.Name != "ROVER" || current.Antenna.Height != 1.5 || len(current.ObservationTypes[gnss.GPS]) != 2 ||
+		len(current.Comments) != len(original.Comments)+1 {
+		t.Errorf("incorrect current header: %v %v %v", current.Marker, current.Antenna, current.ObservationTypes)
+	}
+	if original.Marker.Name != "ALBY00AUS" || original.Antenna.Height != 0 || len(original.ObservationTypes[gnss.GPS]) != 4 {
+		t.Errorf("original header was changed: %v %v %v", original.Marker, original.Antenna, original.ObservationTypes)
+	}
+
+	// The records of the current header are updated in place, with the
+	// comment added before END OF HEADER, and only the GPS observation types
+	// being replaced
+	records := current.GetRecords()
+	if len(records) != len(original.Records)+1 || records[2].String() != epochs[3].HeaderRecords[0].String() ||
+		records[len(records)-2].Key != "COMMENT" || records[len(records)-1].Key != "END OF HEADER" {
+		t.Errorf("incorrect records of the current header:\n%s", header.FormatHeaderRecords(records))
+	}
+	for _, line := range []string{
+		"        1.5000        0.0000        0.0000                  ANTENNA: DELTA H/E/N\n",
+		"G    2 C1C L1C                                              SYS / # / OBS TYPES\n",
+		"R    4 C1C L1C D1C S1C                                      SYS / # / OBS TYPES\n",
+		"E    4 C1C L1C D1C S1C                                      SYS / # / OBS TYPES\n",
+	} {
+		if !strings.Contains(header.FormatHeaderRecords(records), line) {
+			t.Errorf("missing line %q in the current header:\n%s", line, header.FormatHeaderRecords(records))
+		}
+	}
+	if strings.Contains(header.FormatHeaderRecords(original.Records), "ROVER") {
+		t.Errorf("original header records were changed:\n%s", header.FormatHeaderRecords(original.Records))
+	}
+
+	// The moving and new occupation events split the epochs into segments,
+	// with the new occupation starting from the next epoch as it has no time
+	expected := []rinex3.Occupation{
+		{Marker: "ALBY00AUS", Start: epochs[0].Time, End: epochs[0].Time, Epochs: 1},
+		{Marker: "ALBY00AUS", Kinematic: true, Start: epochs[1].Time, End: epochs[2].Time, Epochs: 1},
+		{Marker: "ROVER", Start: epochs[4].Time, End: epochs[8].Time, Epochs: 3},
+	}
+	if occupations := rinexFile.Occupations(); !reflect.DeepEqual(occupations, expected) {
+		t.Errorf("incorrect occupations:\n%+v\n%+v", occupations, expected)
+	}
+
+	// A new marker from header information starts a new occupation, leaving
+	// the epochs before it at the previous marker
+	var occupations []rinex3.Occupation
+	occupations = rinex3.UpdateOccupations(occupations, epochs[0], "ALBY00AUS")
+	occupations = rinex3.UpdateOccupations(occupations, rinex3.EpochRecord{Time: epochs[2].Time, Flag: rinex3.EpochHeaderInformation}, "ROVER")
+	occupations = rinex3.UpdateOccupations(occupations, epochs[4], "ROVER")
+	if len(occupations) != 2 || occupations[0].Marker != "ALBY00AUS" || occupations[0].Epochs != 1 ||
+		occupations[1].Marker != "ROVER" || !occupations[1].Start.Equal(epochs[2].Time) || occupations[1].Epochs != 1 {
+		t.Errorf("incorrect occupations after a new marker: %+v", occupations)
+	}
+
+	// The events are written back, with the header records they contain
+	var buf bytes.Buffer
+	writer, err := rinex3.NewObservationWriter(&buf, original)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, epoch := range epochs {
+		if err := writer.WriteEpoch(epoch); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+	for _, line := range []string{
+		"> 2018 11 24 00 00 30.0000000  2  0\n",
+		">                              3  2\n",
+		"ROVER                                                       MARKER NAME\n",
+		"G    2 C1C L1C                                              SYS / # / OBS TYPES\n",
+		"G05  22111797.084 7 116285143.486 7\n",
+	} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("missing line %q in output:\n%s", line, buf.String())
+		}
+	}
+
+	// RINEX 2 events are parsed in the same way
+	types := []gnss.ObservationCode{{Type: gnss.Pseudorange, Band: 1, Attribute: ' '}}
+	data := "                            3  1\n" + header.FormatHeaderRecord("ROVER", "MARKER NAME") + "\n"
+	event, err := rinex2.ParseEpochRecord(&scanner.Scanner{Reader: bufio.NewReader(strings.NewReader(data))}, types, gnss.GPSTime)
+	if err != nil || event.Flag != rinex3.EpochNewOccupation || len(event.HeaderRecords) != 1 || event.HeaderRecords[0].Key != "MARKER NAME" {
+		t.Errorf("incorrect RINEX 2 event: %+v (%v)", event, err)
+	}
+}
+
+func TestParseRinex2ObservationFile(t *testing.T) {
+	file, err := os.Open("fixtures/alby3280.18o")
+	if err != nil {
+		t.Fatal("failed to open test observation file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	obsHeader, ok := rinexFile.Header.(rinex2.ObservationHeader)
+	if !ok {
+		t.Fatal("couldn't cast RinexHeader interface to RINEX 2 ObservationHeader")
+	}
+	if len(obsHeader.ObservationTypes) != 7 {
+		t.Errorf("incorrect number of observation types: %v", obsHeader.ObservationTypes)
+	}
+
+	epochs, err := rinexFile.Epochs()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(epochs) != 2 {
+		t.Fatalf("incorrect number of epochs: %d", len(epochs))
+	}
+
+	epoch := epochs[1]
+	if epoch.Time.Seconds() != 30 || len(epoch.ObservationRecords) != 13 {
+		t.Errorf("incorrect epoch: %v with %d records", epoch.Time, len(epoch.ObservationRecords))
+	}
+	last := epoch.ObservationRecords[12]
+	if last.Satellite != (gnss.SatelliteID{System: gnss.SBAS, Number: 27}) || len(last.Observations) != 7 {
+		t.Errorf("incorrect observation record for S27: %+v", last)
+	}
+	if obs := last.Observations[6].Value; obs != 40 {
+		t.Errorf("incorrect continuation line observation value: %f", obs)
+	}
+	if offset := epochs[0].ClockOffset; offset != 0.000123456 {
+		t.Errorf("incorrect clock offset: %f", offset)
+	}
+}
+
+func TestParseRinex2WavelengthFactors(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/alby3280.18o")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	text := strings.Replace(string(data), "     1     1                                                WAVELENGTH FACT L1/2\n",
+		"     1     1                                                WAVELENGTH FACT L1/2\n"+
+			"     2     2     9   G05   G13   G15   G18   G20   G21   G24WAVELENGTH FACT L1/2\n"+
+			"                     G26   G29                              WAVELENGTH FACT L1/2\n", 1)
+
+	rinexFile, err := rinex.ParseRinexFile(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	factors := rinexFile.Header.(rinex2.ObservationHeader).WavelengthFactors
+	if factors.L1 != 1 || factors.L2 != 1 || len(factors.Satellites) != 9 {
+		t.Errorf("incorrect wavelength factors: %+v", factors)
+	}
+	for _, number := range []int{5, 24, 26, 29} {
+		if f := factors.Satellites[gnss.SatelliteID{System: gnss.GPS, Number: number}]; f != [2]int{2, 2} {
+			t.Errorf("incorrect wavelength factors of G%02d: %v", number, f)
+		}
+	}
+
+	// The continuation line is required
+	text = strings.Replace(text, "                     G26   G29                              WAVELENGTH FACT L1/2\n", "", 1)
+	if _, err := rinex.ParseRinexFile(strings.NewReader(text)); err == nil {
+		t.Error("expected an error for a missing continuation line")
+	}
+}
+
+func TestObservationLookup(t *testing.T) {
+	file, err := os.Open("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal("failed to open test observation file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	epoch, err := rinexFile.NextEpoch()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	record := epoch.ObservationRecords[0]
+	if obs, ok := record.Get("L1C"); !ok || obs.Value != 116174032.456 || obs.Code.String() != "L1C" {
+		t.Errorf("incorrect L1C observation: %+v", obs)
+	}
+	if obs, ok := record.Pseudorange(1); !ok || obs.Value != 22107568.420 {
+		t.Errorf("incorrect pseudorange: %+v", obs)
+	}
+	if obs, ok := record.Doppler(1); !ok || obs.Value != -1234.567 {
+		t.Errorf("incorrect Doppler: %+v", obs)
+	}
+	if obs, ok := record.SNR(1); !ok || obs.Value != 45.25 {
+		t.Errorf("incorrect SNR: %+v", obs)
+	}
+	if _, ok := record.Phase(2); ok {
+		t.Error("there should be no L2 phase")
+	}
+	if _, ok := record.Get("C1W"); ok {
+		t.Error("there should be no C1W observation")
+	}
+
+	c1w := gnss.ObservationCode{Type: gnss.Pseudorange, Band: 1, Attribute: 'W'}
+	c1c := gnss.ObservationCode{Type: gnss.Pseudorange, Band: 1, Attribute: 'C'}
+	record = rinex3.ObservationRecord{
+		Satellite: gnss.SatelliteID{System: gnss.GPS, Number: 1},
+		Observations: []rinex3.Observation{
+			{Code: c1w, Value: 20000001, Valid: true},
+			{Code: c1c, Value: 20000002, Valid: true},
+		},
+	}
+	if obs, _ := record.Pseudorange(1); obs.Code != c1c {
+		t.Errorf("C1C should be preferred by default: %+v", obs)
+	}
+	if obs, _ := record.Best(gnss.Pseudorange, 1, rinex3.Priority{gnss.GPS: {1: "WC"}}); obs.Code != c1w {
+		t.Errorf("C1W should be preferred: %+v", obs)
+	}
+	record.Observations[1] = rinex3.MissingObservation(c1c)
+	if obs, _ := record.Pseudorange(1); obs.Code != c1w {
+		t.Errorf("blank C1C should fall back to C1W: %+v", obs)
+	}
+
+	// RINEX 2 observations carry their codes too, with P-codes being
+	// pseudoranges
+	file2, err := os.Open("fixtures/alby3280.18o")
+	if err != nil {
+		t.Fatal("failed to open test observation file")
+	}
+	defer file2.Close()
+
+	rinexFile, err = rinex.ParseRinexFile(file2)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	epoch, err = rinexFile.NextEpoch()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	last := epoch.ObservationRecords[len(epoch.ObservationRecords)-1]
+	if obs, ok := last.Pseudorange(2); !ok || obs.Code.String() != "P2" || obs.Value != 21200001.5 {
+		t.Errorf("incorrect RINEX 2 pseudorange: %+v", obs)
+	}
+	if obs, ok := last.SNR(2); !ok || obs.Value != 40 {
+		t.Errorf("incorrect RINEX 2 SNR: %+v", obs)
+	}
+}
+
+func TestParseNavigationFile(t *testing.T) {
+	file, err := os.Open("fixtures/ALBY00AUS_R_20183280000_01D_MN.rnx")
+	if err != nil {
+		t.Fatal("failed to open test navigation file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	navHeader, ok := rinexFile.Header.(rinex3.NavigationHeader)
+	if !ok {
+		t.Fatal("couldn't cast RinexHeader interface to NavigationHeader")
+	}
+	if alpha := navHeader.IonosphericCorrections["GPSA"]; len(alpha) != 4 || alpha[0] != 1.1176e-08 {
+		t.Errorf("incorrect GPSA ionospheric correction: %v", alpha)
+	}
+	if len(navHeader.TimeSystemCorrections) != 2 || navHeader.TimeSystemCorrections[0].ReferenceWeek != 2029 {
+		t.Errorf("incorrect time system corrections: %+v", navHeader.TimeSystemCorrections)
+	}
+	if navHeader.LeapSeconds.Current != 18 {
+		t.Errorf("incorrect leap seconds: %+v", navHeader.LeapSeconds)
+	}
+
+	records, err := rinexFile.NavigationRecords()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(records) != 7 {
+		t.Fatalf("incorrect number of navigation records: %d", len(records))
+	}
+
+	gps, ok := records[0].(*rinex3.GPSEphemeris)
+	if !ok || gps.SatelliteID() != "G05" {
+		t.Fatalf("incorrect first navigation record: %+v", records[0])
+	}
+	if gps.ClockBias != -1.234567890123e-04 || gps.SqrtA != 5153.6 || gps.IODC != 42 || gps.Week != 2029 {
+		t.Errorf("incorrect GPS ephemeris values: %+v", gps)
+	}
+
+	glonass, ok := records[1].(*rinex3.GLONASSEphemeris)
+	if !ok || glonass.AgeOfOperation != -1 {
+		t.Errorf("incorrect GLONASS ephemeris: %+v", records[1])
+	}
+
+	expected := []string{"G05", "R24", "E11", "C06", "J01", "I02", "S27"}
+	for i, record := range records {
+		if record.SatelliteID() != expected[i] {
+			t.Errorf("incorrect satellite for record %d: %s", i, record.SatelliteID())
+		}
+	}
+	if _, ok := records[6].(*rinex3.SBASEphemeris); !ok {
+		t.Errorf("couldn't cast SBAS record to SBASEphemeris")
+	}
+}
+
+func TestParseTruncatedNavigationRecord(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/ALBY00AUS_R_20183280000_01D_MN.rnx")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	// The GPS record is missing its last line
+	head, gps, glonass := strings.Join(lines[:9], ""), lines[9:16], lines[17:21]
+
+	// Only GLONASS records before 3.05 may be missing their last line
+	for _, test := range []struct {
+		version string
+		records []string
+		valid   bool
+	}{
+		{"3.04", glonass, true},
+		{"3.04", gps, false},
+		{"3.05", glonass, false},
+		{"3.05", append(append([]string{}, glonass...), "     0.000000000000D+00 0.000000000000D+00 0.000000000000D+00 0.000000000000D+00\n"), true},
+	} {
+		text := strings.Replace(head, "3.04", test.version, 1) + strings.Join(test.records, "")
+		rinexFile, err := rinex.ParseRinexFile(strings.NewReader(text))
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		records, err := rinexFile.NavigationRecords()
+		if test.valid && (err != nil || len(records) != 1) {
+			t.Errorf("%s %s: %d records (%v)", test.version, test.records[0][:3], len(records), err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%s %s: expected an error", test.version, test.records[0][:3])
+		}
+	}
+}
+
+func TestParseRinex4NavigationFile(t *testing.T) {
+	file, err := os.Open("fixtures/BRDC00IGS_R_20220010000_01D_MN.rnx")
+	if err != nil {
+		t.Fatal("failed to open test navigation file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	navHeader, ok := rinexFile.Header.(rinex4.NavigationHeader)
+	if !ok {
+		t.Fatal("couldn't cast RinexHeader interface to RINEX 4 NavigationHeader")
+	}
+	if navHeader.MergedFiles != 3 || navHeader.LeapSeconds.Current != 18 {
+		t.Errorf("incorrect navigation header: %+v", navHeader)
+	}
+
+	records, err := rinexFile.NavigationRecords()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(records) != 12 {
+		t.Fatalf("incorrect number of navigation records: %d", len(records))
+	}
+
+	lnav, ok := records[0].(*rinex4.Ephemeris)
+	if !ok || lnav.Message != "LNAV" {
+		t.Fatalf("incorrect first navigation record: %+v", records[0])
+	}
+	if gps, ok := lnav.Data.(*rinex3.GPSEphemeris); !ok || gps.SqrtA != 5153.7 || gps.IODC != 77 {
+		t.Errorf("incorrect GPS LNAV ephemeris: %+v", lnav.Data)
+	}
+
+	cnav := records[1].(*rinex4.Ephemeris)
+	if gps, ok := cnav.Data.(*rinex4.CNAVEphemeris); !ok || gps.WeekOfPrediction != 2190 {
+		t.Errorf("incorrect GPS CNAV ephemeris: %+v", cnav.Data)
+	}
+
+	bds := records[5].(*rinex4.Ephemeris)
+	if cnv1, ok := bds.Data.(*rinex4.BeiDouCNAVEphemeris); !ok || cnv1.IODE != 7 || cnv1.ISCB1Cd != 2.8e-2 || cnv1.ISCB2ad != 0 || cnv1.TGDB1Cp != 3e-2 {
+		t.Errorf("incorrect BeiDou CNV1 ephemeris: %+v", bds.Data)
+	}
+	bds = records[6].(*rinex4.Ephemeris)
+	if cnv2, ok := bds.Data.(*rinex4.BeiDouCNAVEphemeris); !ok || bds.Message != "CNV2" || cnv2.IODE != 8 ||
+		cnv2.ISCB1Cd != 0 || cnv2.ISCB2ad != 2.9e-2 || cnv2.TGDB1Cp != 3e-2 || cnv2.TGDB2ap != 3.1e-2 {
+		t.Errorf("incorrect BeiDou CNV2 ephemeris: %+v", bds.Data)
+	}
+
+	sto, ok := records[7].(*rinex4.SystemTimeOffset)
+	if !ok || sto.OffsetType != "GPUT" || sto.UTCIdentifier != "UTC(USNO)" || sto.A0 != -1.862645149231e-09 {
+		t.Errorf("incorrect system time offset record: %+v", records[7])
+	}
+
+	eop, ok := records[8].(*rinex4.EarthOrientation)
+	if !ok || eop.YP != 0.39 || eop.DUT1 != -0.1 {
+		t.Errorf("incorrect earth orientation record: %+v", records[8])
+	}
+
+	if ion, ok := records[9].(*rinex4.IonosphereKlobuchar); !ok || ion.Beta[3] != 196610 || ion.Region != 1 {
+		t.Errorf("incorrect Klobuchar ionosphere record: %+v", records[9])
+	}
+	if ion, ok := records[10].(*rinex4.IonosphereNeQuickG); !ok || ion.AI[0] != 25.25 {
+		t.Errorf("incorrect NeQuick-G ionosphere record: %+v", records[10])
+	}
+	if ion, ok := records[11].(*rinex4.IonosphereBDGIM); !ok || ion.Alpha[8] != 9 {
+		t.Errorf("incorrect BDGIM ionosphere record: %+v", records[11])
+	}
+}
+
+func TestParseMeteorologicalFiles(t *testing.T) {
+	for _, filename := range []string{"fixtures/alby3280.18m", "fixtures/ALBY00AUS_R_20183280000_01D_05M_MM.rnx"} {
+		file, err := os.Open(filename)
+		if err != nil {
+			t.Fatal("failed to open test meteorological file")
+		}
+		defer file.Close()
+
+		rinexFile, err := rinex.ParseRinexFile(file)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+
+		metHeader, ok := rinexFile.Header.(rinex3.MeteorologicalHeader)
+		if !ok {
+			t.Fatal("couldn't cast RinexHeader interface to MeteorologicalHeader")
+		}
+		if len(metHeader.ObservationTypes) != 10 || metHeader.ObservationTypes[9] != "HI" {
+			t.Errorf("%s: incorrect observation types: %v", filename, metHeader.ObservationTypes)
+		}
+		if pr := metHeader.Sensors["PR"]; pr == nil || pr.Model != "PTB330" || pr.Accuracy != 0.1 || pr.Position.Height != 17.5 {
+			t.Errorf("%s: incorrect PR sensor: %+v", filename, pr)
+		}
+
+		records, err := rinexFile.MeteorologicalRecords()
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		if len(records) != 3 {
+			t.Fatalf("%s: incorrect number of meteorological records: %d", filename, len(records))
+		}
+		if records[2].Time.Year() != 2018 || records[2].Time.Minute() != 10 {
+			t.Errorf("%s: incorrect record time: %v", filename, records[2].Time)
+		}
+		if records[1].Values["PR"] != 1013.3 || records[1].Values["RI"] != 0.5 {
+			t.Errorf("%s: incorrect record values: %v", filename, records[1].Values)
+		}
+		if _, ok := records[1].Values["HR"]; ok {
+			t.Errorf("%s: blank HR value should be omitted", filename)
+		}
+	}
+}
+
+func TestParseClockFile(t *testing.T) {
+	file, err := os.Open("fixtures/IGS0OPSFIN_20183280000_01D_05M_CLK.CLK")
+	if err != nil {
+		t.Fatal("failed to open test clock file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	clkHeader, ok := rinexFile.Header.(rinex3.ClockHeader)
+	if !ok {
+		t.Fatal("couldn't cast RinexHeader interface to ClockHeader")
+	}
+	if clkHeader.AnalysisCenter.ID != "IGS" || len(clkHeader.DataTypes) != 2 || len(clkHeader.Satellites) != 16 {
+		t.Errorf("incorrect clock header: %+v", clkHeader)
+	}
+	if len(clkHeader.Stations) != 2 || clkHeader.Stations[0].X != -2441715.436 {
+		t.Errorf("incorrect solution stations: %+v", clkHeader.Stations)
+	}
+
+	records, err := rinexFile.ClockRecords()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(records) != 9 {
+		t.Fatalf("incorrect number of clock records: %d", len(records))
+	}
+	if records[2].NumValues != 4 || records[2].RateSigma != 2e-14 {
+		t.Errorf("incorrect clock record values: %+v", records[2])
+	}
+
+	clocks := rinex3.NewSatelliteClocks(records)
+	start := records[0].Time
+	bias, err := clocks.Bias("G01", start.Add(150*time.Second))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if math.Abs(bias-(-0.999850e-4)) > 1e-15 {
+		t.Errorf("incorrect interpolated clock bias: %e", bias)
+	}
+	if bias, _ := clocks.Bias("G02", start.Add(10*time.Minute)); bias != 1.9988e-4 {
+		t.Errorf("incorrect clock bias at record epoch: %e", bias)
+	}
+	if _, err := clocks.Bias("G01", start.Add(-time.Second)); err == nil {
+		t.Error("expected error interpolating before first clock record")
+	}
+}
+
+func TestParseClockFileVersion304(t *testing.T) {
+	file, err := os.Open("fixtures/IGS0OPSRAP_20183280000_01D_05M_CLK.CLK")
+	if err != nil {
+		t.Fatal("failed to open test clock file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// Header labels are in columns 66-85
+	clkHeader := rinexFile.Header.(rinex3.ClockHeader)
+	if clkHeader.FormatVersion != 3.04 || clkHeader.TimeSystem != "GPS" || clkHeader.LeapSeconds != 18 || len(clkHeader.Satellites) != 2 {
+		t.Errorf("incorrect clock header: %+v", clkHeader)
+	}
+	expected := []rinex3.ClockStation{
+		{Name: "ALBY00AUS", Identifier: "50143M001", X: -2441715.436, Y: 5595123.252, Z: -2580017.697},
+		{Name: "ALIC00AUS", Identifier: "50137M001", X: -4052052.735, Y: 4212835.982, Z: -2545104.589},
+	}
+	if !reflect.DeepEqual(clkHeader.Stations, expected) {
+		t.Errorf("incorrect solution stations: %+v", clkHeader.Stations)
+	}
+	if records := clkHeader.Records; len(records) != 13 || records[2].Key != "COMMENT" || records[12].Key != "END OF HEADER" {
+		t.Errorf("incorrect header records: %+v", records)
+	}
+
+	records, err := rinexFile.ClockRecords()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(records) != 7 || records[0].Name != "ALBY00AUS" || records[1].Bias != 3e-9 || records[3].RateSigma != 2e-14 {
+		t.Errorf("incorrect clock records: %+v", records)
+	}
+
+	// Labels in columns 66-85 are only valid in clock files since 3.04
+	for _, line := range []string{
+		"     3.00           C                   G                        RINEX VERSION / TYPE\n",
+		"     3.04           O                   G                        RINEX VERSION / TYPE\n",
+	} {
+		if _, err := rinex.ParseRinexFile(strings.NewReader(line)); err == nil {
+			t.Errorf("expected an error parsing %q", line)
+		}
+	}
+}
+
+func TestParseCompactObservationFile(t *testing.T) {
+	file, err := os.Open("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.crx")
+	if err != nil {
+		t.Fatal("failed to open test compact observation file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if fv := rinexFile.Header.GetFormatVersion(); fv != 3.03 {
+		t.Errorf("incorrect RINEX Format Version: %f", fv)
+	}
+
+	epochs, err := rinexFile.Epochs()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if len(epochs) != 3 {
+		t.Fatalf("incorrect number of epochs: %d", len(epochs))
+	}
+	if obs := epochs[2].ObservationRecords[3].Observations[1].Value; obs != 129073580.314 {
+		t.Errorf("incorrect decompressed observation value: %f", obs)
+	}
+}
+
+func TestOpenCompressedRinexFiles(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		fileType string
+	}{
+		{"fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx.Z", "O"},
+		{"fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.crx.gz", "O"},
+		{"fixtures/ALBY00AUS_R_20183280000_01D_MN.rnx.bz2", "N"},
+		{"fixtures/ALBY00AUS_R_20183280000_01D_05M_MM.rnx.zip", "M"},
+		{"fixtures/alby3280.18d.Z", "O"},
+		{"", "O"}, // Detected from the magic number of a temporary file
+	} {
+		if test.path == "" {
+			data, err := ioutil.ReadFile("fixtures/alby3280.18d.Z")
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			tmp, err := ioutil.TempFile("", "observations")
+			if err != nil {
+				t.Fatal(err.Error())
+			}
+			defer os.Remove(tmp.Name())
+			if _, err := tmp.Write(data); err != nil {
+				t.Fatal(err.Error())
+			}
+			tmp.Close()
+			test.path = tmp.Name()
+		}
+
+		rinexFile, err := rinex.OpenRinexFile(test.path)
+		if err != nil {
+			t.Fatalf("%s: %s", test.path, err.Error())
+		}
+		if ft := rinexFile.Header.GetFileType(); ft != test.fileType {
+			t.Errorf("%s: incorrect File Type: %s", test.path, ft)
+		}
+
+		var count int
+		switch test.fileType {
+		case "O":
+			epochs, err := rinexFile.Epochs()
+			if err != nil {
+				t.Fatalf("%s: %s", test.path, err.Error())
+			}
+			count = len(epochs)
+		case "N":
+			records, err := rinexFile.NavigationRecords()
+			if err != nil {
+				t.Fatalf("%s: %s", test.path, err.Error())
+			}
+			count = len(records)
+		case "M":
+			records, err := rinexFile.MeteorologicalRecords()
+			if err != nil {
+				t.Fatalf("%s: %s", test.path, err.Error())
+			}
+			count = len(records)
+		}
+		if count == 0 {
+			t.Errorf("%s: no records read", test.path)
+		}
+
+		if err := rinexFile.Close(); err != nil {
+			t.Errorf("%s: %s", test.path, err.Error())
+		}
+	}
+}
+
+func TestWriteObservationFile(t *testing.T) {
+	file, err := os.Open("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal("failed to open test observation file")
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	epochs, err := rinexFile.Epochs()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	header := rinexFile.Header.(rinex3.ObservationHeader)
+
+	// Remove a Doppler observation, and all observations after the phase of
+	// another satellite, which also has a loss of lock, while a third has a
+	// genuine zero Doppler
+	d1c := epochs[1].ObservationRecords[0].Observations[2].Code
+	epochs[1].ObservationRecords[0].Observations[2] = rinex3.MissingObservation(d1c)
+	var removed []gnss.ObservationCode
+	for _, obs := range epochs[1].ObservationRecords[1].Observations[2:] {
+		removed = append(removed, obs.Code)
+	}
+	epochs[1].ObservationRecords[1].Observations = epochs[1].ObservationRecords[1].Observations[:2]
+	epochs[1].ObservationRecords[1].Observations[1].LLI = 1
+	epochs[1].ObservationRecords[1].Observations[1].HasLLI = true
+	epochs[1].ObservationRecords[1].Observations[1].SignalStrength = 7
+	epochs[1].ObservationRecords[1].Observations[1].HasSSI = true
+	epochs[1].ObservationRecords[2].Observations[2].Value = 0
+
+	var buf bytes.Buffer
+	writer, err := rinex3.NewObservationWriter(&buf, header)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, epoch := range epochs {
+		if err := writer.WriteEpoch(epoch); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	output := buf.String()
+	for _, line := range []string{
+		"     3.03           OBSERVATION DATA    M (MIXED)           RINEX VERSION / TYPE\n",
+		"G    4 C1C L1C D1C S1C                                      SYS / # / OBS TYPES\n",
+		"  2018    11    24     0     0    0.0000000     GPS         TIME OF FIRST OBS\n",
+		"> 2018 11 24 00 00 30.0000000  0  4\n",
+		"G05  22107568.420 7 116174032.456 7     -1234.567          45.250\n",
+		"G05  22108977.975 7 116211069.466 7                        45.250\n",
+		"G13  23454110.969 7 123195064.44917\n",
+		"R24  20124584.433 7 107683950.632 7         0.000          47.000\n",
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("missing line %q in output:\n%s", line, output)
+		}
+	}
+
+	written, err := rinex.ParseRinexFile(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	writtenHeader := written.Header.(rinex3.ObservationHeader)
+	if !reflect.DeepEqual(writtenHeader.ObservationTypes, header.ObservationTypes) {
+		t.Errorf("incorrect observation types: %v", writtenHeader.ObservationTypes)
+	}
+	if writtenHeader.Marker != header.Marker || writtenHeader.Receiver != header.Receiver {
+		t.Errorf("incorrect marker or receiver: %v %v", writtenHeader.Marker, writtenHeader.Receiver)
+	}
+	writtenEpochs, err := written.Epochs()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// The removed observations are read back as missing observations
+	for _, code := range removed {
+		epochs[1].ObservationRecords[1].Observations = append(epochs[1].ObservationRecords[1].Observations, rinex3.MissingObservation(code))
+	}
+	if !reflect.DeepEqual(writtenEpochs, epochs) {
+		t.Errorf("written epochs do not match:\n%v\n%v", writtenEpochs, epochs)
+	}
+}
+
+func parseNavigationFixture(t *testing.T, path string) (rinex.RinexFile, []rinex3.NavigationRecord) {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s", path)
+	}
+	defer file.Close()
+
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	records, err := rinexFile.NavigationRecords()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	return rinexFile, records
+}
+
+func TestWriteNavigationFile(t *testing.T) {
+	rinexFile, records := parseNavigationFixture(t, "fixtures/ALBY00AUS_R_20183280000_01D_MN.rnx")
+	header := rinexFile.Header.(rinex3.NavigationHeader)
+	header.FormatVersion = 3.05
+
+	var buf bytes.Buffer
+	writer, err := rinex3.NewNavigationWriter(&buf, header)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, record := range records {
+		if err := writer.WriteRecord(record); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	output := buf.String()
+	for _, line := range []string{
+		"     3.05           N: GNSS NAV DATA    M (MIXED)           RINEX VERSION / TYPE\n",
+		"GPSA   1.1176D-08 -1.4901D-08 -5.9605D-08  1.1921D-07       IONOSPHERIC CORR\n",
+		"GPUT -9.3132257462D-10-9.769962617D-15 503808 2029        0 TIME SYSTEM CORR\n",
+		"G05 2018 11 24 00 00 00-1.234567890123D-04-2.046363078989D-12 0.000000000000D+00\n",
+		"     0.000000000000D+00 0.000000000000D+00 0.000000000000D+00 0.000000000000D+00\n",
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("missing line %q in output:\n%s", line, output)
+		}
+	}
+
+	written, err := rinex.ParseRinexFile(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	writtenHeader := written.Header.(rinex3.NavigationHeader)
+	if !reflect.DeepEqual(writtenHeader.TimeSystemCorrections, header.TimeSystemCorrections) {
+		t.Errorf("incorrect time system corrections: %+v", writtenHeader.TimeSystemCorrections)
+	}
+	if !reflect.DeepEqual(writtenHeader.IonosphericCorrections, header.IonosphericCorrections) {
+		t.Errorf("incorrect ionospheric corrections: %+v", writtenHeader.IonosphericCorrections)
+	}
+	writtenRecords, err := written.NavigationRecords()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(writtenRecords, records) {
+		t.Errorf("written navigation records do not match:\n%+v\n%+v", writtenRecords, records)
+	}
+}
+
+func TestWriteRinex4NavigationFile(t *testing.T) {
+	rinexFile, records := parseNavigationFixture(t, "fixtures/BRDC00IGS_R_20220010000_01D_MN.rnx")
+
+	var buf bytes.Buffer
+	writer, err := rinex4.NewNavigationWriter(&buf, rinexFile.Header.(rinex4.NavigationHeader))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	for _, record := range records {
+		if err := writer.WriteRecord(record); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	output := buf.String()
+	for _, line := range []string{
+		"        3                                                   MERGED FILE\n",
+		"> EPH G01 LNAV\nG01 2022 01 01 00 00 00 1.000000000000E-03 2.000000000000E-03 3.000000000000E-03\n",
+		"     2.800000000000E-02 2.900000000000E-02 3.000000000000E-02 3.100000000000E-02\n",
+		"     3.600000000000E-02                                       7.000000000000E+00\n", // Spare fields
+		"> EPH C20 CNV2\nC20 2022 01 01 00 00 00 1.100000000000E-03 2.100000000000E-03 3.100000000000E-03\n",
+		"                        2.900000000000E-02 3.000000000000E-02 3.100000000000E-02\n", // ISC_B2ad of CNV2
+		"     2.800000000000E-02                    3.000000000000E-02 3.100000000000E-02\n", // ISC_B1Cd of CNV1
+		"> STO G01 LNAV\n    2022 01 01 00 00 00 GPUT                                UTC(USNO)\n",
+		"                        3.900000000000E-01-2.000000000000E-04 0.000000000000E+00\n",
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("missing line %q in output:\n%s", line, output)
+		}
+	}
+
+	written, err := rinex.ParseRinexFile(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if written.Header.(rinex4.NavigationHeader).MergedFiles != 3 {
+		t.Errorf("incorrect merged files: %+v", written.Header)
+	}
+	writtenRecords, err := written.NavigationRecords()
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if !reflect.DeepEqual(writtenRecords, records) {
+		t.Errorf("written navigation records do not match:\n%+v\n%+v", writtenRecords, records)
+	}
+}
+
+func TestHeaderTimeConverters(t *testing.T) {
+	rinexFile, _ := parseNavigationFixture(t, "fixtures/ALBY00AUS_R_20183280000_01D_MN.rnx")
+	navHeader := rinexFile.Header.(rinex3.NavigationHeader)
+
+	start := gnss.Date(gnss.GPSTime, 2018, time.November, 24, 0, 0, 0)
+	converter := navHeader.TimeConverter(start)
+	if len(converter.Corrections) != 2 || converter.Corrections[0].From != gnss.GPSTime || converter.Corrections[1].From != gnss.GalileoTime {
+		t.Errorf("incorrect corrections: %+v", converter.Corrections)
+	}
+	// The leap second in the header is already in the table
+	if len(converter.LeapSeconds.LeapSeconds) != len(gnsstime.DefaultLeapSeconds.LeapSeconds) {
+		t.Errorf("incorrect leap seconds: %+v", converter.LeapSeconds)
+	}
+	if utc, err := converter.Convert(start, gnss.UTC); err != nil || !utc.GoTime().Equal(start.GoTime().Add(-18*time.Second)) {
+		t.Errorf("incorrect UTC: %s (%v)", utc, err)
+	}
+
+	// An unknown leap second applies from the start of the file
+	obsHeader := rinex3.ObservationHeader{TimeOfFirstObs: gnss.Date(gnss.GPSTime, 2027, time.March, 1, 0, 0, 0)}
+	obsHeader.LeapSeconds = rinex3.LeapSeconds{Current: 5, Future: 5, System: "BDS"}
+	converter = obsHeader.TimeConverter()
+	if offset := converter.LeapSeconds.AtUTC(obsHeader.TimeOfFirstObs.GoTime()); offset != 19 {
+		t.Errorf("incorrect leap seconds from a BDS LEAP SECONDS record: %d", offset)
+	}
+	if offset := converter.LeapSeconds.AtUTC(start.GoTime()); offset != 18 {
+		t.Errorf("incorrect leap seconds before a LEAP SECONDS record: %d", offset)
+	}
+
+	// Future leap seconds apply from the end of the given day, which is
+	// numbered from 1 for GPS and 0 for BDS, being 2017-01-01 for both
+	before := time.Date(2016, time.December, 31, 12, 0, 0, 0, time.UTC)
+	after := time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for _, ls := range []rinex3.LeapSeconds{
+		{Current: 17, Future: 18, FutureWeek: 1929, FutureDay: 7},
+		{Current: 3, Future: 4, FutureWeek: 573, FutureDay: 6, System: "BDS"},
+	} {
+		table := ls.Apply(gnsstime.DefaultLeapSeconds, gnss.Time{})
+		if len(table.LeapSeconds) != len(gnsstime.DefaultLeapSeconds.LeapSeconds) || table.AtUTC(before) != 17 || table.AtUTC(after) != 18 {
+			t.Errorf("incorrect leap seconds from a LEAP SECONDS record of %+v: %+v", ls, table.LeapSeconds[len(table.LeapSeconds)-2:])
+		}
+	}
+}
+
+func TestMergeNavigationRecords(t *testing.T) {
+	_, first := parseNavigationFixture(t, "fixtures/ALBY00AUS_R_20183280000_01D_MN.rnx")
+	_, second := parseNavigationFixture(t, "fixtures/ALBY00AUS_R_20183280000_01D_MN.rnx")
+
+	// The same GPS ephemeris received earlier by the second station, and a
+	// Galileo ephemeris with the same IODnav but a different clock bias
+	second[0].(*rinex3.GPSEphemeris).TransmissionTime = -30
+	second[2].(*rinex3.GalileoEphemeris).ClockBias = 1e-4
+
+	merged := rinex3.MergeNavigationRecords(first, second)
+	if len(merged) != len(first)+1 {
+		t.Fatalf("incorrect number of merged records: %d", len(merged))
+	}
+
+	expected := []string{"C06", "E11", "E11", "G05", "I02", "J01", "R24", "S27"}
+	for i, record := range merged {
+		if record.SatelliteID() != expected[i] {
+			t.Errorf("incorrect satellite for merged record %d: %s", i, record.SatelliteID())
+		}
+	}
+	if gps := merged[3].(*rinex3.GPSEphemeris); gps.TransmissionTime != -30 {
+		t.Errorf("merged GPS ephemeris is not the earliest transmission: %+v", gps)
+	}
+
+	// RINEX 3 ephemerides are written to RINEX 4 as legacy messages
+	var buf bytes.Buffer
+	for _, record := range merged {
+		text, err := rinex4.FormatNavigationRecord(record)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		buf.WriteString(text)
+	}
+	for _, line := range []string{"> EPH C06 D1\n", "> EPH E11 INAV\n", "> EPH R24 FDMA\n", "> EPH S27 SBAS\n"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("missing record header %q", line)
+		}
+	}
+
+	// Identical RINEX 4 records are dropped, while others are kept
+	_, first = parseNavigationFixture(t, "fixtures/BRDC00IGS_R_20220010000_01D_MN.rnx")
+	_, second = parseNavigationFixture(t, "fixtures/BRDC00IGS_R_20220010000_01D_MN.rnx")
+	second[7].(*rinex4.SystemTimeOffset).A0 = 1e-9
+	if merged = rinex3.MergeNavigationRecords(first, second); len(merged) != len(first)+1 {
+		t.Errorf("incorrect number of merged RINEX 4 records: %d", len(merged))
+	}
+}
+
+func TestFormatFortranFloat(t *testing.T) {
+	for value, expected := range map[float64]string{
+		1.5e-3:               " 1.500000000000D-03",
+		-2.5e99:              "-2.500000000000D+99",
+		1e-100:               " 0.000000000000D+00",
+		1e100:                " 9.999999999999D+99",
+		-2e150:               "-9.999999999999D+99",
+		9.9999999999999e99:   " 9.999999999999D+99", // Rounds up to 1E+100
+		math.Inf(1):          " 9.999999999999D+99",
+		math.MaxFloat64 * -1: "-9.999999999999D+99",
+	} {
+		if formatted := rinex3.FormatFortranFloat(value, 19, 12, "D"); formatted != expected {
+			t.Errorf("incorrect formatting of %g: %q", value, formatted)
+		}
+	}
+	if formatted := rinex3.FormatFortranFloat(1e100, 6, 0, "E"); formatted != " 9E+99" {
+		t.Errorf("incorrect formatting without decimals: %q", formatted)
+	}
+	if formatted := rinex3.FormatFortranFloat(math.NaN(), 19, 12, "D"); formatted != strings.Repeat(" ", 19) {
+		t.Errorf("incorrect formatting of NaN: %q", formatted)
+	}
+
+	// A NaN value of a record is written as a blank field
+	record := &rinex3.GPSEphemeris{EphemerisHeader: rinex3.EphemerisHeader{Satellite: "G01"}, ClockBias: math.NaN(), ClockDrift: 1}
+	text, err := rinex3.FormatNavigationRecord(record, 3.04)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	lines := strings.Split(text, "\n")
+	if !strings.HasPrefix(lines[0], "G01 0001 01 01 00 00 00                    1.000000000000D+00") {
+		t.Errorf("incorrect formatting of a NaN field: %q", lines[0])
+	}
+}
+
+func TestParseListRecords(t *testing.T) {
+	fixture, err := ioutil.ReadFile("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+
+	// A blank count is an error whether or not the counts fill a line
+	nine := "G    9 C1C L1C D1C S1C C2W L2W D2W S2W C5Q                  SYS / # / OBS TYPES\n"
+	ten := "G   10 C1C L1C D1C S1C C2W L2W D2W S2W C5Q L5Q              SYS / # / OBS TYPES\n"
+	tests := []struct {
+		types, counts string
+		expected      []int
+	}{
+		{nine, "   G05   120   120   120   120   120   120   120   120   120PRN / # OF OBS\n", []int{120, 120, 120, 120, 120, 120, 120, 120, 120}},
+		{nine, "   G05   120   120   120   120   120   120   120   120      PRN / # OF OBS\n", nil},
+		{ten, "   G05   120   120   120   120   120   120   120   120   120PRN / # OF OBS\n" +
+			"         120                                                PRN / # OF OBS\n", []int{120, 120, 120, 120, 120, 120, 120, 120, 120, 120}},
+		{ten, "   G05   120   120   120   120   120   120   120   120      PRN / # OF OBS\n", nil},
+	}
+	for _, test := range tests {
+		data := strings.Replace(string(fixture), "G    4 C1C L1C D1C S1C                                      SYS / # / OBS TYPES\n", test.types, 1)
+		data = strings.Replace(data, "    30.000                                                  INTERVAL\n", test.counts+"    30.000                                                  INTERVAL\n", 1)
+
+		rinexFile, err := rinex.ParseRinexFile(strings.NewReader(data))
+		if test.expected == nil {
+			if err == nil {
+				t.Errorf("expected an error for blank counts:\n%s", test.counts)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("failed to parse counts: %v\n%s", err, test.counts)
+			continue
+		}
+		satellite := gnss.SatelliteID{System: gnss.GPS, Number: 5}
+		if counts := rinexFile.Header.(rinex3.ObservationHeader).ObservationCounts[satellite]; !reflect.DeepEqual(counts, test.expected) {
+			t.Errorf("incorrect counts: %v", counts)
+		}
+	}
+}
+
+func TestParseObservationHeaderModes(t *testing.T) {
+	fixture, err := ioutil.ReadFile("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	data := strings.Replace(string(fixture),
+		"ALBY00AUS                                                   MARKER NAME\n",
+		"ALBY00AUS                                                   MARKER NAME\n"+
+			"some vendor specific value                                  VENDOR RECORD\n", 1)
+	data = strings.Replace(data,
+		"G    4 C1C L1C D1C S1C                                      SYS / # / OBS TYPES\n",
+		"G CC2NONCC            ftp://igs.org                         SYS / DCBS APPLIED\n"+
+			"G    4 C1C L1C D1C S1C                                      SYS / # / OBS TYPES\n", 1)
+	data = strings.Replace(data,
+		"    30.000                                                  INTERVAL\n",
+		"     2                                                      # OF SATELLITES\n"+
+			"   G05   120   120   120   120                              PRN / # OF OBS\n"+
+			"    30.000                                                  INTERVAL\n", 1)
+
+	_, err = rinex.ParseRinexFile(strings.NewReader(data))
+	if recordErr, ok := err.(header.RecordError); !ok || recordErr.Line != 4 || recordErr.Key != "VENDOR RECORD" {
+		t.Errorf("incorrect strict mode error: %v", err)
+	}
+
+	rinexFile, err := rinex.ParseRinexFileMode(strings.NewReader(data), header.Lenient)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	obsHeader := rinexFile.Header.(rinex3.ObservationHeader)
+	var lines []int
+	for _, warning := range obsHeader.Warnings {
+		lines = append(lines, warning.Line)
+	}
+	if !reflect.DeepEqual(lines, []int{4, 12, 19}) {
+		t.Errorf("incorrect lenient mode warnings: %v", obsHeader.Warnings)
+	}
+	if len(obsHeader.UnknownRecords) != 1 || strings.TrimSpace(obsHeader.UnknownRecords[0].Value) != "some vendor specific value" {
+		t.Errorf("incorrect unknown records: %+v", obsHeader.UnknownRecords)
+	}
+	if obsHeader.Interval != 30 || len(obsHeader.ObservationTypes[gnss.Galileo]) != 4 {
+		t.Errorf("records following warnings were not parsed: %+v", obsHeader)
+	}
+	if epochs, err := rinexFile.Epochs(); err != nil || len(epochs) != 3 {
+		t.Errorf("failed to parse epochs after lenient header: %v", err)
+	}
+
+	// Parsing must always end, even without an END OF HEADER
+	truncated := data[:strings.Index(data, "                                                            END OF HEADER")]
+	for _, mode := range []header.ParseMode{header.Strict, header.Lenient} {
+		if _, err := rinex.ParseRinexFileMode(strings.NewReader(truncated), mode); err == nil {
+			t.Errorf("expected error for missing END OF HEADER in mode %d", mode)
+		}
+	}
+}
+
+func TestObservationHeaderRecords(t *testing.T) {
+	fixture, err := ioutil.ReadFile("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	records := []string{
+		"        1.0000        2.0000        3.0000                  ANTENNA: DELTA X/Y/Z\n",
+		"G L1C   0.0010        0.0020        0.1230                  ANTENNA: PHASECENTER\n",
+		"        0.0000        0.0000        1.0000                  ANTENNA: B.SIGHT XYZ\n",
+		"       90.0000                                              ANTENNA: ZERODIR AZI\n",
+		"        0.0000        1.0000        0.0000                  ANTENNA: ZERODIR XYZ\n",
+		"        4.0000        5.0000        6.0000                  CENTER OF MASS: XYZ\n",
+		"     1                                                      RCV CLOCK OFFS APPL\n",
+		"G CC2NONCC          ftp://igs.org/pub/DCB                   SYS / DCBS APPLIED\n",
+		"E PCV tool          ftp://igs.org/pub/igs14.atx             SYS / PCVS APPLIED\n",
+		"G   10   2 L1C D1C                                          SYS / SCALE FACTOR\n",
+		"R  100                                                      SYS / SCALE FACTOR\n",
+		"G L1C  0.25000  12 G01 G02 G03 G04 G05 G06 G07 G08 G09 G10  SYS / PHASE SHIFT\n",
+		"                   G11 G12                                  SYS / PHASE SHIFT\n",
+		"E L1C  0.00000                                              SYS / PHASE SHIFT\n",
+		"R                                                           SYS / PHASE SHIFT\n",
+		" 10 R01  1 R02 -4 R03  5 R04  6 R05  1 R06 -4 R07  5 R08  6 GLONASS SLOT / FRQ #\n",
+		"    R09 -2 R10 -7                                           GLONASS SLOT / FRQ #\n",
+		"     2                                                      # OF SATELLITES\n",
+		"   G05   120   118   120   120                              PRN / # OF OBS\n",
+		"   R13    98    98    97    98                              PRN / # OF OBS\n",
+	}
+	endOfHeader := "                                                            END OF HEADER\n"
+	data := strings.Replace(string(fixture), endOfHeader, strings.Join(records, "")+endOfHeader, 1)
+
+	rinexFile, err := rinex.ParseRinexFile(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	h := rinexFile.Header.(rinex3.ObservationHeader)
+
+	if h.Antenna.DeltaXYZ != (rinex3.XYZ{X: 1, Y: 2, Z: 3}) || h.Antenna.BoreSight != (rinex3.XYZ{Z: 1}) ||
+		h.Antenna.ZeroDirectionAzimuth != 90 || h.Antenna.ZeroDirection != (rinex3.XYZ{Y: 1}) ||
+		h.CenterOfMass != (rinex3.XYZ{X: 4, Y: 5, Z: 6}) {
+		t.Errorf("incorrect antenna records: %+v %+v", h.Antenna, h.CenterOfMass)
+	}
+	l1c := gnss.ObservationCode{Type: gnss.Phase, Band: 1, Attribute: 'C'}
+	d1c := gnss.ObservationCode{Type: gnss.Doppler, Band: 1, Attribute: 'C'}
+	if !reflect.DeepEqual(h.Antenna.PhaseCenters, []rinex3.PhaseCenter{{System: gnss.GPS, ObservationType: l1c, Position: rinex3.XYZ{X: 0.001, Y: 0.002, Z: 0.123}}}) {
+		t.Errorf("incorrect phase centers: %+v", h.Antenna.PhaseCenters)
+	}
+	if !h.ClockOffsetApplied {
+		t.Error("receiver clock offset should be applied")
+	}
+	if !reflect.DeepEqual(h.DCBsApplied, []rinex3.AppliedCorrection{{System: gnss.GPS, Program: "CC2NONCC", Source: "ftp://igs.org/pub/DCB"}}) ||
+		!reflect.DeepEqual(h.PCVsApplied, []rinex3.AppliedCorrection{{System: gnss.Galileo, Program: "PCV tool", Source: "ftp://igs.org/pub/igs14.atx"}}) {
+		t.Errorf("incorrect applied corrections: %+v %+v", h.DCBsApplied, h.PCVsApplied)
+	}
+	if !reflect.DeepEqual(h.ScaleFactors, []rinex3.ScaleFactor{
+		{System: gnss.GPS, Factor: 10, ObservationTypes: []gnss.ObservationCode{l1c, d1c}},
+		{System: gnss.GLONASS, Factor: 100},
+	}) {
+		t.Errorf("incorrect scale factors: %+v", h.ScaleFactors)
+	}
+	expectedShifts := []rinex3.PhaseShift{
+		{System: gnss.GPS, ObservationType: l1c, Correction: 0.25},
+		{System: gnss.Galileo, ObservationType: l1c},
+		{System: gnss.GLONASS},
+	}
+	for i := 1; i <= 12; i++ {
+		expectedShifts[0].Satellites = append(expectedShifts[0].Satellites, gnss.SatelliteID{System: gnss.GPS, Number: i})
+	}
+	if !reflect.DeepEqual(h.PhaseShifts, expectedShifts) {
+		t.Errorf("incorrect phase shifts: %+v", h.PhaseShifts)
+	}
+	expectedSlots := map[gnss.SatelliteID]int{}
+	for i, slot := range []int{1, -4, 5, 6, 1, -4, 5, 6, -2, -7} {
+		expectedSlots[gnss.SatelliteID{System: gnss.GLONASS, Number: i + 1}] = slot
+	}
+	if !reflect.DeepEqual(h.GLONASSSlots, expectedSlots) {
+		t.Errorf("incorrect GLONASS slots: %v", h.GLONASSSlots)
+	}
+	if h.LeapSeconds.Current != 18 || h.NumSatellites != 2 {
+		t.Errorf("incorrect leap seconds or number of satellites: %v %d", h.LeapSeconds, h.NumSatellites)
+	}
+	if !reflect.DeepEqual(h.ObservationCounts, map[gnss.SatelliteID][]int{
+		{System: gnss.GPS, Number: 5}:      {120, 118, 120, 120},
+		{System: gnss.GLONASS, Number: 13}: {98, 98, 97, 98},
+	}) {
+		t.Errorf("incorrect observation counts: %v", h.ObservationCounts)
+	}
+
+	// The records are written as they were read
+	output := strings.Join(rinex3.FormatObservationHeader(h), "")
+	for _, record := range append(records, "    18                                                      LEAP SECONDS\n") {
+		if !strings.Contains(output, record) {
+			t.Errorf("missing record %q in output:\n%s", record, output)
+		}
+	}
+}
+
+func TestHeaderRecords(t *testing.T) {
+	for _, path := range []string{
+		"fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx",
+		"fixtures/alby3280.18o",
+		"fixtures/ALBY00AUS_R_20183280000_01D_MN.rnx",
+		"fixtures/BRDC00IGS_R_20220010000_01D_MN.rnx",
+		"fixtures/ALBY00AUS_R_20183280000_01D_05M_MM.rnx",
+		"fixtures/IGS0OPSFIN_20183280000_01D_05M_CLK.CLK",
+	} {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		rinexFile, err := rinex.ParseRinexFile(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		end := strings.Index(string(data), "END OF HEADER") + len("END OF HEADER\n")
+		if formatted := header.FormatHeaderRecords(rinexFile.Header.GetRecords()); formatted != string(data[:end]) {
+			t.Errorf("%s: header records don't match the file:\n%s", path, formatted)
+		}
+	}
+}
+
+func TestReplaceHeaderRecords(t *testing.T) {
+	fixture, err := ioutil.ReadFile("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	// A comment and a non-standard label are kept in place
+	data := strings.Replace(string(fixture),
+		"ALBY00AUS                                                   MARKER NAME\n",
+		"ALBY00AUS                                                   MARKER NAME\n"+
+			"antenna swapped 2018-11-20                                  COMMENT\n"+
+			"some vendor specific value                                  VENDOR RECORD   \n", 1)
+
+	rinexFile, err := rinex.ParseRinexFileMode(strings.NewReader(data), header.Lenient)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	records := rinexFile.Header.GetRecords()
+	end := strings.Index(data, "END OF HEADER") + len("END OF HEADER\n")
+	if formatted := header.FormatHeaderRecords(records); formatted != data[:end] {
+		t.Fatalf("header records don't match the file:\n%s", formatted)
+	}
+
+	records = header.ReplaceHeaderRecords(records, "MARKER NAME", []string{header.FormatHeaderRecord("ALIC00AUS", "MARKER NAME")})
+	records = header.ReplaceHeaderRecords(records, "RCV CLOCK OFFS APPL", []string{header.FormatHeaderRecord("     1", "RCV CLOCK OFFS APPL")})
+	expected := strings.Replace(data[:end],
+		"ALBY00AUS                                                   MARKER NAME\n",
+		"ALIC00AUS                                                   MARKER NAME\n", 1)
+	expected = strings.Replace(expected,
+		"                                                            END OF HEADER\n",
+		"     1                                                      RCV CLOCK OFFS APPL\n"+
+			"                                                            END OF HEADER\n", 1)
+	if formatted := header.FormatHeaderRecords(records); formatted != expected {
+		t.Errorf("incorrect replaced header records:\n%s", formatted)
+	}
+
+	records = header.ReplaceHeaderRecords(records, "VENDOR RECORD", nil)
+	if formatted := header.FormatHeaderRecords(records); strings.Contains(formatted, "VENDOR RECORD") {
+		t.Errorf("record wasn't removed:\n%s", formatted)
+	}
+
+	// Updates replace records, apart from comments and appended records
+	updates := header.NewHeaderRecords([]string{
+		header.FormatHeaderRecord("ALBY00AUS", "MARKER NAME"),
+		header.FormatHeaderRecord("second comment", "COMMENT"),
+		header.FormatHeaderRecord("    19", "LEAP SECONDS"),
+		header.FormatHeaderRecord("     0", "RCV CLOCK OFFS APPL"),
+	})
+	updated := header.UpdateHeaderRecords(records, updates, "LEAP SECONDS")
+	expected = strings.Replace(data[:end], "some vendor specific value                                  VENDOR RECORD   \n", "", 1)
+	expected = strings.Replace(expected,
+		"antenna swapped 2018-11-20                                  COMMENT\n",
+		"antenna swapped 2018-11-20                                  COMMENT\n"+
+			"second comment                                              COMMENT\n", 1)
+	expected = strings.Replace(expected,
+		"    18                                                      LEAP SECONDS\n",
+		"    18                                                      LEAP SECONDS\n"+
+			"    19                                                      LEAP SECONDS\n", 1)
+	expected = strings.Replace(expected,
+		"                                                            END OF HEADER\n",
+		"     0                                                      RCV CLOCK OFFS APPL\n"+
+			"                                                            END OF HEADER\n", 1)
+	if formatted := header.FormatHeaderRecords(updated); formatted != expected {
+		t.Errorf("incorrect updated header records:\n%s", formatted)
+	}
+	if formatted := header.FormatHeaderRecords(records); strings.Contains(formatted, "second comment") {
+		t.Errorf("records were changed by an update:\n%s", formatted)
+	}
+}
+
+func TestEditObservationHeader(t *testing.T) {
+	data, err := ioutil.ReadFile("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	rinexFile, err := rinex.ParseRinexFile(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	h := rinexFile.Header.(rinex3.ObservationHeader)
+
+	markerName, antennaType := "ALIC00AUS", "LEIAR25.R3      LEIT"
+	edit := rinex3.ObservationHeaderEdit{
+		MarkerName:   &markerName,
+		AntennaType:  &antennaType,
+		AntennaDelta: &[3]float64{0.0550, 0, 0},
+	}
+	changed := edit.Apply(&h)
+	if !reflect.DeepEqual(changed, []string{"MARKER NAME", "ANT # / TYPE", "ANTENNA: DELTA H/E/N"}) {
+		t.Errorf("incorrect changed records: %v", changed)
+	}
+	if changed := edit.Apply(&h); len(changed) != 0 {
+		t.Errorf("applying an edit twice should change nothing: %v", changed)
+	}
+	h.SetReceiver("3013513", "SEPT POLARX5", "5.3.2")
+
+	end := bytes.Index(data, []byte("END OF HEADER")) + len("END OF HEADER\n")
+	expected := string(data[:end])
+	for old, new := range map[string]string{
+		"ALBY00AUS                                                   MARKER NAME\n":          "ALIC00AUS                                                   MARKER NAME\n",
+		"5117K80005          JAVRINGANT_DM   SCIS                    ANT # / TYPE\n":         "5117K80005          LEIAR25.R3      LEIT                    ANT # / TYPE\n",
+		"        0.0000        0.0000        0.0000                  ANTENNA: DELTA H/E/N\n": "        0.0550        0.0000        0.0000                  ANTENNA: DELTA H/E/N\n",
+		"3013512             SEPT POLARX5        5.2.0               REC # / TYPE / VERS\n":  "3013513             SEPT POLARX5        5.3.2               REC # / TYPE / VERS\n",
+	} {
+		expected = strings.Replace(expected, old, new, 1)
+	}
+	if formatted := header.FormatHeaderRecords(h.Records); formatted != expected {
+		t.Errorf("incorrect edited header:\n%s", formatted)
+	}
+
+	// The edited header is parsed with the new values
+	var buf bytes.Buffer
+	buf.WriteString(header.FormatHeaderRecords(h.Records))
+	io.Copy(&buf, rinexFile.Data())
+	edited, err := rinex.ParseRinexFile(&buf)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	editedHeader := edited.Header.(rinex3.ObservationHeader)
+	if editedHeader.Marker.Name != markerName || editedHeader.Antenna.Type != antennaType ||
+		editedHeader.Antenna.Height != 0.055 || editedHeader.Receiver.Version != "5.3.2" {
+		t.Errorf("incorrect edited header fields: %+v", editedHeader)
+	}
+	if epochs, err := edited.Epochs(); err != nil || len(epochs) != 3 {
+		t.Errorf("failed to parse epochs of edited file: %v", err)
+	}
+}
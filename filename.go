@@ -2,29 +2,71 @@ package rinex
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/rinex2"
+	"github.com/go-gnss/rinex/rinex3"
 )
 
+// Filename holds the components of a RINEX filename, where a zero Duration
+// or Frequency is unspecified ("U"). Frequency is the sampling interval, so
+// 50 Hz ("50Z") is 20ms.
 type Filename struct {
-	StationName string
-	DataSource  string // rune ?
-	StartTime   string // time.Time ?
-	Duration    string // time.Duration ?
-	Frequency   string // time.Duration ?
-	FileType    string
-	FileFormat  string // rnx, crx,
+	StationName string // Nine character ID, e.g. ALBY00AUS
+	DataSource  string // R (receiver), S (stream) or U (unknown)
+	StartTime   time.Time
+	Duration    time.Duration
+	Frequency   time.Duration
+	FileType    string // e.g. MO, GN, MM
+	FileFormat  string // rnx or crx
 	Compression string
+	LongName    bool // Whether the filename is a RINEX 3 long filename, rather than a short filename
 }
 
+const year = 365 * 24 * time.Hour
+
+var (
+	durationUnits map[byte]time.Duration = map[byte]time.Duration{
+		'S': time.Second,
+		'M': time.Minute,
+		'H': time.Hour,
+		'D': 24 * time.Hour,
+		'Y': year,
+	}
+	// Units are tried from largest to smallest when formatting
+	durationUnitOrder  string = "YDHMS"
+	frequencyUnitOrder string = "DHMS"
+)
+
 var (
-	// TODO:
-	//ShortNamePattern *regexp.Regexp = regexp.MustCompile(
-	//	`^(?<siteId>[\w]{4})` +
-	//    `(?<dayOfYear>\d{3})` +
-	//    `((?<daily>0)|((?<hour>[a-x])(?<minute>(00|15|30|45)?)))` +
-	//    `\.(?<year>\d{2})` +
-	//    `(?<fileTypeCode>[abcdfghlmnopqs])` +
-	//    `((\.(?<extension>((z)|(gz)|(bz2)|(zip))))?)$`)
+	ShortNamePattern *regexp.Regexp = regexp.MustCompile(
+		`^(?P<siteId>[\w]{4})` +
+			`(?P<dayOfYear>\d{3})` +
+			`((?P<daily>0)|((?P<hour>[a-xA-X])(?P<minute>(00|15|30|45)?)))` +
+			`\.(?P<year>\d{2})` +
+			`(?P<fileTypeCode>[dfghlmnopqDFGHLMNOPQ])` +
+			`(\.(?P<compression>([zZ])|(gz)|(bz2)|(zip)))?$`)
+
+	// shortNameFileTypes maps the file type letter of a short name to the
+	// file type and format of a long name
+	shortNameFileTypes map[string][2]string = map[string][2]string{
+		"o": {"MO", "rnx"},
+		"d": {"MO", "crx"}, // Hatanaka compressed observations
+		"m": {"MM", "rnx"},
+		"n": {"GN", "rnx"},
+		"g": {"RN", "rnx"},
+		"l": {"EN", "rnx"},
+		"f": {"CN", "rnx"},
+		"q": {"JN", "rnx"},
+		"h": {"SN", "rnx"},
+		"p": {"MN", "rnx"},
+	}
 
 	LongNamePattern *regexp.Regexp = regexp.MustCompile(
 		`^(?P<stationName>[\w]{4}\d{2}[a-zA-Z]{3})_` +
@@ -37,10 +79,12 @@ var (
 			`\.(?P<format>((rnx)|(crx)))(\.(?P<compression>.*))?$`)
 )
 
+// ParseRinexFilename parses a RINEX 3 long filename, or a RINEX 2 style
+// short filename (ssssdddf.yyt).
 func ParseRinexFilename(name string) (filename Filename, err error) {
 	matchGroups := LongNamePattern.FindStringSubmatch(name)
 	if len(matchGroups) == 0 {
-		return filename, errors.New("invalid RINEX filename")
+		return parseShortFilename(name)
 	}
 
 	groups := map[string]string{}
@@ -53,23 +97,247 @@ func ParseRinexFilename(name string) (filename Filename, err error) {
 	filename = Filename{
 		StationName: groups["stationName"],
 		DataSource:  groups["dataSource"],
-		StartTime:   groups["startTime"],
-		Duration:    groups["duration"],
 		FileFormat:  groups["format"],
 		Compression: groups["compression"],
+		LongName:    true,
+	}
+	filename.StartTime, err = time.Parse("20060021504", groups["startTime"])
+	if err != nil {
+		return filename, fmt.Errorf("invalid RINEX filename start time: %v", err)
 	}
+	filename.Duration = parseDuration(groups["duration"])
 
+	var frequency string
 	switch {
 	case groups["navFileTypeCode"] != "":
 		filename.FileType = groups["navFileTypeCode"]
-		filename.Frequency = groups["navFrequency"]
+		frequency = groups["navFrequency"]
 	case groups["obsFileTypeCode"] != "":
 		filename.FileType = groups["obsFileTypeCode"]
-		filename.Frequency = groups["obsFrequency"]
+		frequency = groups["obsFrequency"]
 	case groups["metFileTypeCode"] != "":
 		filename.FileType = groups["metFileTypeCode"]
-		filename.Frequency = groups["metFrequency"]
+		frequency = groups["metFrequency"]
+	}
+	filename.Frequency = parseFrequency(frequency)
+
+	return filename, nil
+}
+
+// parseDuration parses a two digit duration followed by its unit, being
+// zero for an unspecified ("U") duration
+func parseDuration(duration string) time.Duration {
+	if len(duration) != 3 {
+		return 0
+	}
+	value, _ := strconv.Atoi(duration[:2])
+	return time.Duration(value) * durationUnits[duration[2]]
+}
+
+// parseFrequency parses a two digit frequency followed by its unit, where C
+// is hundreds of Hz and Z is Hz, returning the sampling interval
+func parseFrequency(frequency string) time.Duration {
+	if len(frequency) != 3 {
+		return 0
+	}
+	value, _ := strconv.Atoi(frequency[:2])
+	switch frequency[2] {
+	case 'C':
+		value *= 100
+		fallthrough
+	case 'Z':
+		if value == 0 {
+			return 0
+		}
+		return time.Second / time.Duration(value)
+	default:
+		return parseDuration(frequency)
+	}
+}
+
+// formatDuration formats a duration using the largest unit which it is a
+// whole number of, returning "00U" if it is unspecified or can't be
+// represented in two digits
+func formatDuration(d time.Duration, units string) string {
+	for _, unit := range []byte(units) {
+		size := durationUnits[unit]
+		if d > 0 && d%size == 0 && d/size < 100 {
+			return fmt.Sprintf("%02d%c", d/size, unit)
+		}
+	}
+	return "00U"
+}
+
+func formatFrequency(interval time.Duration) string {
+	if interval > 0 && interval < time.Second && time.Second%interval == 0 {
+		hz := time.Second / interval
+		if hz < 100 {
+			return fmt.Sprintf("%02dZ", hz)
+		}
+		if hz%100 == 0 && hz/100 < 100 {
+			return fmt.Sprintf("%02dC", hz/100)
+		}
+	}
+	return formatDuration(interval, frequencyUnitOrder)
+}
+
+// String returns the RINEX 3 long filename. The station name is padded with
+// "00XXX" (monument 0, receiver 0 and an unknown country) if it is only a
+// four character site ID, and the data source defaults to U (unknown). The
+// frequency is omitted for navigation and meteorological files if it is
+// unspecified.
+func (f Filename) String() string {
+	station := strings.ToUpper(f.StationName)
+	if len(station) == 4 {
+		station += "00XXX"
+	}
+	source := f.DataSource
+	if source == "" {
+		source = "U"
+	}
+	format := f.FileFormat
+	if format == "" {
+		format = "rnx"
+	}
+
+	name := fmt.Sprintf("%s_%s_%s_%s_", station, source, f.StartTime.Format("20060021504"), formatDuration(f.Duration, durationUnitOrder))
+	if strings.HasSuffix(f.FileType, "O") || f.Frequency != 0 {
+		name += formatFrequency(f.Frequency) + "_"
+	}
+	name += f.FileType + "." + format
+	if f.Compression != "" {
+		name += "." + f.Compression
+	}
+	return name
+}
+
+// NewObservationFilename returns the Filename of an observation file from
+// its header, using the MARKER NAME, TIME OF FIRST OBS and INTERVAL, along
+// with the nominal duration of the file such as 24 hours for a daily file.
+func NewObservationFilename(h RinexHeader, duration time.Duration) (filename Filename, err error) {
+	var markerName, system string
+	var firstObs gnss.Time
+	var interval float64
+	switch h := h.(type) {
+	case rinex3.ObservationHeader:
+		markerName, system, firstObs, interval = h.Marker.Name, h.SatelliteSystem, h.TimeOfFirstObs, h.Interval
+	case rinex2.ObservationHeader:
+		markerName, system, firstObs, interval = h.Marker.Name, h.SatelliteSystem, h.TimeOfFirstObs, h.Interval
+	default:
+		return filename, fmt.Errorf("can't derive an observation filename from a %T", h)
+	}
+
+	station := strings.ToUpper(strings.Replace(markerName, " ", "", -1))
+	if len(station) != 9 {
+		if len(station) < 4 {
+			return filename, fmt.Errorf("invalid marker name \"%s\"", markerName)
+		}
+		station = station[:4]
+	}
+	if system == "" || system == " " {
+		system = "G"
+	}
+
+	return Filename{
+		StationName: station,
+		DataSource:  "U",
+		StartTime:   firstObs.GoTime(),
+		Duration:    duration,
+		Frequency:   time.Duration(math.Round(interval*1e3)) * time.Millisecond,
+		FileType:    system + "O",
+		FileFormat:  "rnx",
+		LongName:    true,
+	}, nil
+}
+
+// parseShortFilename parses a short filename, where the session is "0" for
+// a daily file, an hour letter "a" to "x" for an hourly file, or an hour
+// letter followed by the minute for a 15 minute file. Short names have no
+// data source or frequency, and the satellite system of observation files is
+// assumed to be mixed.
+func parseShortFilename(name string) (filename Filename, err error) {
+	matchGroups := ShortNamePattern.FindStringSubmatch(name)
+	if len(matchGroups) == 0 {
+		return filename, errors.New("invalid RINEX filename")
+	}
+
+	groups := map[string]string{}
+	for i, name := range ShortNamePattern.SubexpNames() {
+		if name != "" {
+			groups[name] = matchGroups[i]
+		}
 	}
 
+	year, _ := strconv.Atoi(groups["year"])
+	if year < 80 {
+		year += 2000
+	} else {
+		year += 1900
+	}
+
+	hour, minute, duration := 0, 0, 24*time.Hour
+	if groups["daily"] == "" {
+		hour = int(strings.ToLower(groups["hour"])[0] - 'a')
+		duration = time.Hour
+		if groups["minute"] != "" {
+			minute, _ = strconv.Atoi(groups["minute"])
+			duration = 15 * time.Minute
+		}
+	}
+
+	dayOfYear, _ := strconv.Atoi(groups["dayOfYear"])
+	fileType := shortNameFileTypes[strings.ToLower(groups["fileTypeCode"])]
+	filename = Filename{
+		StationName: groups["siteId"],
+		StartTime:   time.Date(year, 1, dayOfYear, hour, minute, 0, 0, time.UTC),
+		Duration:    duration,
+		FileType:    fileType[0],
+		FileFormat:  fileType[1],
+		Compression: groups["compression"],
+	}
 	return filename, nil
 }
+
+// ShortName returns the RINEX 2 style short filename (ssssdddf.yyt) of a
+// Filename, which must have a duration of a day or more, an hour or 15
+// minutes. The station name is truncated to its four character site ID.
+func (f Filename) ShortName() (string, error) {
+	if len(f.StationName) < 4 {
+		return "", fmt.Errorf("invalid station name \"%s\"", f.StationName)
+	}
+
+	start := f.StartTime
+	var session string
+	switch {
+	case f.Duration == 15*time.Minute:
+		session = fmt.Sprintf("%c%02d", 'a'+start.Hour(), start.Minute())
+	case f.Duration == time.Hour:
+		session = string(rune('a' + start.Hour()))
+	case f.Duration >= 24*time.Hour:
+		session = "0"
+	default:
+		return "", fmt.Errorf("duration %v can't be represented by a short filename", f.Duration)
+	}
+
+	var fileType string
+	for code, longType := range shortNameFileTypes {
+		if longType == [2]string{f.FileType, f.FileFormat} {
+			fileType = code
+		}
+	}
+	if fileType == "" && strings.HasSuffix(f.FileType, "O") {
+		fileType = "o" // Observations of a single satellite system
+		if f.FileFormat == "crx" {
+			fileType = "d"
+		}
+	}
+	if fileType == "" {
+		return "", fmt.Errorf("file type \"%s\" can't be represented by a short filename", f.FileType)
+	}
+
+	name := fmt.Sprintf("%s%03d%s.%02d%s", strings.ToLower(f.StationName[:4]), start.YearDay(), session, start.Year()%100, fileType)
+	if f.Compression != "" {
+		name += "." + f.Compression
+	}
+	return name, nil
+}
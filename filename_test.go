@@ -1,14 +1,20 @@
 package rinex_test
 
 import (
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-gnss/rinex"
 )
 
-// TODO: Test RinexFilename attributes
 // TODO: Format logs better
 
+func date(year, dayOfYear, hour, minute int) time.Time {
+	return time.Date(year, 1, dayOfYear, hour, minute, 0, 0, time.UTC)
+}
+
 func TestObservationLongName(t *testing.T) {
 	_, err := rinex.ParseRinexFilename("SITE00AUS_R_20183280000_01D_30S_MO.rnx")
 	if err != nil {
@@ -39,3 +45,174 @@ func TestInvalidFilename(t *testing.T) {
 		t.Error("ParseRinexFilename did not return invalid RINEX filename error")
 	}
 }
+
+func TestShortName(t *testing.T) {
+	day := 24 * time.Hour
+	for name, expected := range map[string]rinex.Filename{
+		"alby3280.18o":    {StationName: "alby", StartTime: date(2018, 328, 0, 0), Duration: day, FileType: "MO", FileFormat: "rnx"},
+		"alby3280.18d.Z":  {StationName: "alby", StartTime: date(2018, 328, 0, 0), Duration: day, FileType: "MO", FileFormat: "crx", Compression: "Z"},
+		"alby328b.18n.gz": {StationName: "alby", StartTime: date(2018, 328, 1, 0), Duration: time.Hour, FileType: "GN", FileFormat: "rnx", Compression: "gz"},
+		"alby328x45.98m":  {StationName: "alby", StartTime: date(1998, 328, 23, 45), Duration: 15 * time.Minute, FileType: "MM", FileFormat: "rnx"},
+		"ALBY001a00.20G":  {StationName: "ALBY", StartTime: date(2020, 1, 0, 0), Duration: 15 * time.Minute, FileType: "RN", FileFormat: "rnx"},
+	} {
+		filename, err := rinex.ParseRinexFilename(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if filename != expected {
+			t.Errorf("%s: incorrect filename %+v", name, filename)
+		}
+
+		shortName, err := filename.ShortName()
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if shortName != strings.ToLower(name[:len(name)-len(filename.Compression)])+filename.Compression {
+			t.Errorf("%s: incorrect short name %s", name, shortName)
+		}
+	}
+
+	for _, name := range []string{"alby328y.18o", "alby328a10.18o", "alby3280.18x", "alby3280.18o.xz"} {
+		if _, err := rinex.ParseRinexFilename(name); err == nil {
+			t.Errorf("%s: expected invalid RINEX filename error", name)
+		}
+	}
+}
+
+func TestLongNameToShortName(t *testing.T) {
+	filename, err := rinex.ParseRinexFilename("ALBY00AUS_R_20183281500_01H_30S_GO.crx.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortName, err := filename.ShortName()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shortName != "alby328p.18d.gz" {
+		t.Errorf("incorrect short name %s", shortName)
+	}
+
+	filename.Duration = 30 * time.Minute
+	if _, err := filename.ShortName(); err == nil {
+		t.Error("expected error for a duration which can't be represented by a short name")
+	}
+}
+
+func TestLongNameFields(t *testing.T) {
+	for name, expected := range map[string]rinex.Filename{
+		"ALBY00AUS_R_20183280000_01D_30S_MO.rnx": {
+			StationName: "ALBY00AUS", DataSource: "R", StartTime: date(2018, 328, 0, 0),
+			Duration: 24 * time.Hour, Frequency: 30 * time.Second, FileType: "MO", FileFormat: "rnx", LongName: true,
+		},
+		"ALBY00AUS_S_20183281215_15M_50Z_GO.crx.gz": {
+			StationName: "ALBY00AUS", DataSource: "S", StartTime: date(2018, 328, 12, 15),
+			Duration: 15 * time.Minute, Frequency: 20 * time.Millisecond, FileType: "GO", FileFormat: "crx", Compression: "gz", LongName: true,
+		},
+		"ALBY00AUS_R_20183280000_01H_01C_EO.rnx": {
+			StationName: "ALBY00AUS", DataSource: "R", StartTime: date(2018, 328, 0, 0),
+			Duration: time.Hour, Frequency: 10 * time.Millisecond, FileType: "EO", FileFormat: "rnx", LongName: true,
+		},
+		"ALBY00AUS_U_20180010000_01Y_00U_MO.rnx": {
+			StationName: "ALBY00AUS", DataSource: "U", StartTime: date(2018, 1, 0, 0),
+			Duration: 365 * 24 * time.Hour, FileType: "MO", FileFormat: "rnx", LongName: true,
+		},
+		"BRDC00IGS_R_20220010000_01D_MN.rnx": {
+			StationName: "BRDC00IGS", DataSource: "R", StartTime: date(2022, 1, 0, 0),
+			Duration: 24 * time.Hour, FileType: "MN", FileFormat: "rnx", LongName: true,
+		},
+		"ALBY00AUS_R_20183280000_01H_05M_GN.rnx.gz": {
+			StationName: "ALBY00AUS", DataSource: "R", StartTime: date(2018, 328, 0, 0),
+			Duration: time.Hour, Frequency: 5 * time.Minute, FileType: "GN", FileFormat: "rnx", Compression: "gz", LongName: true,
+		},
+		"ALBY00AUS_R_20183280000_01D_05M_MM.rnx": {
+			StationName: "ALBY00AUS", DataSource: "R", StartTime: date(2018, 328, 0, 0),
+			Duration: 24 * time.Hour, Frequency: 5 * time.Minute, FileType: "MM", FileFormat: "rnx", LongName: true,
+		},
+	} {
+		filename, err := rinex.ParseRinexFilename(name)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if filename != expected {
+			t.Errorf("%s: incorrect filename %+v", name, filename)
+		}
+		if filename.String() != name {
+			t.Errorf("%s: incorrect long name %s", name, filename.String())
+		}
+	}
+
+	filename, _ := rinex.ParseRinexFilename("alby328b.18d.Z")
+	if filename.LongName {
+		t.Error("short name parsed as a long name")
+	}
+	if name := filename.String(); name != "ALBY00XXX_U_20183280100_01H_00U_MO.crx.Z" {
+		t.Errorf("incorrect long name from short name: %s", name)
+	}
+}
+
+func TestNewObservationFilename(t *testing.T) {
+	for path, expected := range map[string]string{
+		"fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx": "ALBY00AUS_U_20183280000_01D_30S_MO.rnx",
+		"fixtures/alby3280.18o":                           "ALBY00XXX_U_20183280000_01D_30S_MO.rnx",
+	} {
+		file, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rinexFile, err := rinex.ParseRinexFile(file)
+		file.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		filename, err := rinex.NewObservationFilename(rinexFile.Header, 24*time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filename.String() != expected {
+			t.Errorf("%s: incorrect filename %s", path, filename)
+		}
+	}
+}
+
+func TestValidateFilename(t *testing.T) {
+	file, err := os.Open("fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rinexFile, err := rinex.ParseRinexFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, fields := range map[string][]string{
+		"ALBY00AUS_R_20183280000_01D_30S_MO.rnx":    nil,
+		"alby3280.18o":                              nil,
+		"ALBY00AUS_R_20183280000_01H_30S_MO.crx.gz": nil,
+		"ALBZ00AUS_R_20183290000_01D_01S_GO.rnx":    {"StationName", "StartTime", "Frequency", "FileType"},
+		"ALBY00AUS_R_20183272300_01H_30S_MO.rnx":    {"StartTime"},
+		"ALBY00AUS_R_20183280000_01D_MN.rnx":        {"FileType"},
+		"ALBY00NZL_R_20183280000_01D_30S_MO.rnx":    {"StationName"},
+	} {
+		filename, err := rinex.ParseRinexFilename(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mismatches := rinex.ValidateFilename(filename, rinexFile.Header)
+		var mismatchFields []string
+		for _, mismatch := range mismatches {
+			mismatchFields = append(mismatchFields, mismatch.Field)
+		}
+		if strings.Join(mismatchFields, ",") != strings.Join(fields, ",") {
+			t.Errorf("%s: incorrect mismatches %v", name, mismatches)
+		}
+	}
+
+	filename, _ := rinex.ParseRinexFilename("ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if mismatches := rinex.ValidateFilename(filename, nil); len(mismatches) != 1 || mismatches[0].Field != "FileType" {
+		t.Errorf("incorrect mismatches without a header: %v", mismatches)
+	}
+}
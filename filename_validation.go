@@ -0,0 +1,110 @@
+package rinex
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/rinex2"
+	"github.com/go-gnss/rinex/rinex3"
+)
+
+// FilenameMismatch is a component of a Filename which disagrees with the
+// header of the file
+type FilenameMismatch struct {
+	Field    string // Filename field, e.g. StationName, StartTime, Frequency or FileType
+	Filename string // Value given by the filename
+	Header   string // Value given by the header
+}
+
+func (m FilenameMismatch) Error() string {
+	return fmt.Sprintf("filename %s \"%s\" does not match header \"%s\"", m.Field, m.Filename, m.Header)
+}
+
+// ValidateFilename compares a Filename with the header of the file, returning
+// a FilenameMismatch for each of the following which disagree:
+//
+// - StationName against MARKER NAME, using only the four character site ID
+// if either is not a nine character ID
+// - StartTime against TIME OF FIRST OBS, which must be within the Duration
+// following the StartTime
+// - Frequency against INTERVAL, if both are specified
+// - FileType against the header's file type, and for observation files with
+// long filenames the satellite system code (e.g. MO or GO) against the
+// observed systems, as short filenames don't give the satellite system
+//
+// A nil header is a FileType mismatch, as there's no file type to match.
+func ValidateFilename(f Filename, h RinexHeader) (mismatches []FilenameMismatch) {
+	if h == nil {
+		return []FilenameMismatch{{"FileType", f.FileType, ""}}
+	}
+
+	var markerName string
+	var firstObs *gnss.Time
+	var interval float64
+	var systems []string
+
+	switch h := h.(type) {
+	case rinex3.ObservationHeader:
+		markerName, firstObs, interval = h.Marker.Name, &h.TimeOfFirstObs, h.Interval
+		for _, system := range rinex3.SortSatelliteSystems(h.ObservationTypes) {
+			systems = append(systems, system.String())
+		}
+	case rinex2.ObservationHeader:
+		markerName, firstObs, interval = h.Marker.Name, &h.TimeOfFirstObs, h.Interval
+		systems = []string{h.SatelliteSystem}
+		if h.SatelliteSystem == "" || h.SatelliteSystem == " " {
+			systems = []string{"G"}
+		}
+	case rinex3.MeteorologicalHeader:
+		markerName = h.Marker.Name
+	}
+
+	if markerName != "" && !matchStationName(f.StationName, markerName) {
+		mismatches = append(mismatches, FilenameMismatch{"StationName", f.StationName, markerName})
+	}
+
+	if firstObs != nil && !firstObs.IsZero() {
+		t := firstObs.GoTime()
+		if t.Before(f.StartTime) || (f.Duration != 0 && !t.Before(f.StartTime.Add(f.Duration))) {
+			mismatches = append(mismatches, FilenameMismatch{
+				"StartTime", f.StartTime.Format("2006-01-02T15:04"), t.Format("2006-01-02T15:04:05"),
+			})
+		}
+	}
+
+	headerInterval := time.Duration(math.Round(interval*1e3)) * time.Millisecond
+	if f.Frequency != 0 && headerInterval != 0 && f.Frequency != headerInterval {
+		mismatches = append(mismatches, FilenameMismatch{"Frequency", f.Frequency.String(), headerInterval.String()})
+	}
+
+	if len(f.FileType) == 2 && f.FileType[1:] != h.GetFileType() {
+		mismatches = append(mismatches, FilenameMismatch{"FileType", f.FileType, h.GetFileType()})
+	} else if len(systems) > 0 && len(f.FileType) == 2 && f.LongName {
+		expected := "M"
+		if len(systems) == 1 {
+			expected = systems[0]
+		}
+		if f.FileType[:1] != expected {
+			sort.Strings(systems)
+			mismatches = append(mismatches, FilenameMismatch{"FileType", f.FileType, strings.Join(systems, "")})
+		}
+	}
+
+	return mismatches
+}
+
+func matchStationName(station, markerName string) bool {
+	station = strings.ToUpper(station)
+	markerName = strings.ToUpper(strings.Replace(markerName, " ", "", -1))
+	if len(station) == 9 && len(markerName) == 9 {
+		return station == markerName
+	}
+	if len(station) < 4 || len(markerName) < 4 {
+		return false
+	}
+	return station[:4] == markerName[:4]
+}
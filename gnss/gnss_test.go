@@ -0,0 +1,184 @@
+package gnss_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-gnss/rinex/gnss"
+)
+
+func TestParseSatelliteID(t *testing.T) {
+	tests := map[string]gnss.SatelliteID{
+		"G05":  {System: gnss.GPS, Number: 5},
+		"G 5":  {System: gnss.GPS, Number: 5},
+		"R24":  {System: gnss.GLONASS, Number: 24},
+		"S120": {System: gnss.SBAS, Number: 20},
+		"S27":  {System: gnss.SBAS, Number: 27},
+		"J01":  {System: gnss.QZSS, Number: 1},
+	}
+	for s, expected := range tests {
+		id, err := gnss.ParseSatelliteID(s)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", s, err)
+			continue
+		}
+		if id != expected {
+			t.Errorf("incorrect satellite for %q: %+v", s, id)
+		}
+		if err = id.Validate(3.04); err != nil {
+			t.Errorf("%s should be valid: %v", id, err)
+		}
+	}
+
+	for _, s := range []string{"", "G", "X01", "M01", "G-1", "GAB"} {
+		if _, err := gnss.ParseSatelliteID(s); err == nil {
+			t.Errorf("%q should be invalid", s)
+		}
+	}
+}
+
+func TestSatelliteID(t *testing.T) {
+	sbas := gnss.SatelliteID{System: gnss.SBAS, Number: 20}
+	if sbas.String() != "S20" || sbas.PRN() != 120 {
+		t.Errorf("incorrect SBAS satellite %s with PRN %d", sbas, sbas.PRN())
+	}
+	qzss := gnss.SatelliteID{System: gnss.QZSS, Number: 1}
+	if qzss.String() != "J01" || qzss.PRN() != 193 {
+		t.Errorf("incorrect QZSS satellite %s with PRN %d", qzss, qzss.PRN())
+	}
+
+	invalid := map[gnss.SatelliteID]float64{
+		{System: gnss.GPS, Number: 0}:     3.04,
+		{System: gnss.GPS, Number: 33}:    3.04,
+		{System: gnss.SBAS, Number: 19}:   3.04,
+		{System: gnss.BeiDou, Number: 1}:  2.11,
+		{System: gnss.IRNSS, Number: 1}:   3.02,
+		{System: gnss.Mixed, Number: 1}:   3.04,
+		{System: gnss.Galileo, Number: 1}: 2,
+	}
+	for id, version := range invalid {
+		if err := id.Validate(version); err == nil {
+			t.Errorf("%s should be invalid in RINEX %.2f", id, version)
+		}
+	}
+}
+
+func TestSatelliteSystem(t *testing.T) {
+	system, err := gnss.ParseSatelliteSystem("s")
+	if err != nil || system != gnss.SBAS || system.Name() != "SBAS PAYLOAD" {
+		t.Errorf("incorrect satellite system %q: %v", system, err)
+	}
+	if _, err = gnss.ParseSatelliteSystem("X"); err == nil {
+		t.Error("X should be an invalid satellite system")
+	}
+	if err = gnss.Mixed.Validate(2); err != nil {
+		t.Errorf("mixed files should be valid in RINEX 2: %v", err)
+	}
+}
+
+func TestParseObservationCode(t *testing.T) {
+	tests := map[string]gnss.ObservationCode{
+		"C1C": {Type: gnss.Pseudorange, Band: 1, Attribute: 'C'},
+		"L5Q": {Type: gnss.Phase, Band: 5, Attribute: 'Q'},
+		"D2W": {Type: gnss.Doppler, Band: 2, Attribute: 'W'},
+		"S1C": {Type: gnss.SignalStrength, Band: 1, Attribute: 'C'},
+		"P2":  {Type: gnss.PCode, Band: 2},
+		"L1":  {Type: gnss.Phase, Band: 1},
+	}
+	for s, expected := range tests {
+		code, err := gnss.ParseObservationCode(s)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", s, err)
+			continue
+		}
+		if code != expected || code.String() != s {
+			t.Errorf("incorrect observation code for %q: %+v (%s)", s, code, code)
+		}
+	}
+
+	for _, s := range []string{"", "C", "Q1C", "CXC", "C1C1", "C1c"} {
+		if _, err := gnss.ParseObservationCode(s); err == nil {
+			t.Errorf("%q should be invalid", s)
+		}
+	}
+}
+
+func TestValidateObservationCode(t *testing.T) {
+	tests := []struct {
+		Code    string
+		System  gnss.SatelliteSystem
+		Version float64
+		Valid   bool
+	}{
+		{Code: "C1C", System: gnss.GPS, Version: 3.04, Valid: true},
+		{Code: "L2W", System: gnss.GPS, Version: 3.04, Valid: true},
+		{Code: "L2N", System: gnss.GPS, Version: 3.04, Valid: true},
+		{Code: "C2N", System: gnss.GPS, Version: 3.04, Valid: false},
+		{Code: "C5C", System: gnss.GPS, Version: 3.04, Valid: false},
+		{Code: "C6C", System: gnss.GPS, Version: 3.04, Valid: false},
+		{Code: "C1", System: gnss.GPS, Version: 3.04, Valid: false},
+		{Code: "P1", System: gnss.GPS, Version: 3.04, Valid: false},
+		{Code: "X1", System: gnss.GPS, Version: 3.04, Valid: true},
+		{Code: "C4A", System: gnss.GLONASS, Version: 3.04, Valid: true},
+		{Code: "C1I", System: gnss.BeiDou, Version: 3.02, Valid: true},
+		{Code: "C1I", System: gnss.BeiDou, Version: 3.03, Valid: false},
+		{Code: "C1P", System: gnss.BeiDou, Version: 3.04, Valid: true},
+		{Code: "C5A", System: gnss.IRNSS, Version: 3.03, Valid: true},
+		{Code: "C5A", System: gnss.IRNSS, Version: 3.02, Valid: false},
+		{Code: "P2", System: gnss.GPS, Version: 2.11, Valid: true},
+		{Code: "C5", System: gnss.GPS, Version: 2.11, Valid: true},
+		{Code: "C5", System: gnss.GLONASS, Version: 2.11, Valid: false},
+		{Code: "C1C", System: gnss.GPS, Version: 2.11, Valid: false},
+	}
+	for _, test := range tests {
+		code, err := gnss.ParseObservationCode(test.Code)
+		if err != nil {
+			t.Errorf("failed to parse %q: %v", test.Code, err)
+			continue
+		}
+		if err = code.Validate(test.System, test.Version); (err == nil) != test.Valid {
+			t.Errorf("incorrect validation of %s %s in RINEX %.2f: %v", test.System, code, test.Version, err)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	epoch := gnss.Date(gnss.GPSTime, 2018, time.November, 24, 0, 0, 30.3)
+	if epoch.GoTime() != time.Date(2018, time.November, 24, 0, 0, 30, 300000000, time.UTC) {
+		t.Errorf("incorrect time: %s", epoch)
+	}
+	if epoch.String() != "2018-11-24 00:00:30.3000000 GPS" || epoch.Seconds() != 30.3 {
+		t.Errorf("incorrect time: %s with %f seconds", epoch, epoch.Seconds())
+	}
+	if year, day := epoch.DayOfYear(); year != 2018 || day != 328 {
+		t.Errorf("incorrect day of year: %d %d", year, day)
+	}
+	if week, tow := epoch.GPSWeek(); week != 2028 || tow != 518430.3 {
+		t.Errorf("incorrect GPS week: %d %f", week, tow)
+	}
+	if !gnss.GPSWeekTime(2028, 518430.3).Equal(epoch) {
+		t.Errorf("incorrect time of GPS week: %s", gnss.GPSWeekTime(2028, 518430.3))
+	}
+
+	// Times are rounded to 100 ns
+	next := epoch.Add(100*time.Millisecond + 49*time.Nanosecond)
+	if next.Sub(epoch) != 100*time.Millisecond || !next.After(epoch) || !epoch.Before(next) {
+		t.Errorf("incorrect time after %s: %s", epoch, next)
+	}
+	if rounded := gnss.NewTime(time.Date(2018, time.November, 24, 0, 0, 0, 50, time.UTC), gnss.UTC); rounded.GoTime().Nanosecond() != 100 {
+		t.Errorf("incorrect rounding: %s", rounded)
+	}
+	if epoch.Equal(gnss.NewTime(epoch.GoTime(), gnss.GalileoTime)) {
+		t.Error("times in different time systems should not be equal")
+	}
+
+	if system, err := gnss.ParseTimeSystem("BDT"); err != nil || system != gnss.BeiDouTime {
+		t.Errorf("incorrect time system %s: %v", system, err)
+	}
+	if _, err := gnss.ParseTimeSystem("TAI"); err == nil {
+		t.Error("TAI should be an invalid time system")
+	}
+	if gnss.SBAS.TimeSystem() != gnss.GPSTime || gnss.Mixed.TimeSystem() != "" {
+		t.Error("incorrect time systems of satellite systems")
+	}
+}
@@ -0,0 +1,130 @@
+package gnss
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ObservationType is the first character of an observation code
+type ObservationType byte
+
+const (
+	Pseudorange     ObservationType = 'C'
+	PCode           ObservationType = 'P' // RINEX 2 P-code pseudorange
+	Phase           ObservationType = 'L'
+	Doppler         ObservationType = 'D'
+	SignalStrength  ObservationType = 'S'
+	IonosphereDelay ObservationType = 'I'
+	ChannelNumber   ObservationType = 'X'
+)
+
+// ObservationCode is an observation type such as C1C, broken down into its
+// type (C), frequency band (1) and attribute (C), being the tracking mode or
+// channel. RINEX 2 codes such as C1 and P2 have no attribute.
+type ObservationCode struct {
+	Type      ObservationType
+	Band      int
+	Attribute byte // Zero for RINEX 2 codes
+}
+
+var (
+	observationTypes string = "CPLDSIX"
+
+	// bandAttributes are the attributes of each frequency band of each
+	// satellite system in RINEX 3
+	bandAttributes map[SatelliteSystem]map[int]string = map[SatelliteSystem]map[int]string{
+		GPS:     {1: "CSLXPWYMN", 2: "CDSLXPWYMN", 5: "IQX"},
+		GLONASS: {1: "CP", 2: "CP", 3: "IQX", 4: "ABX", 6: "ABX"},
+		Galileo: {1: "ABCXZ", 5: "IQX", 6: "ABCXZ", 7: "IQX", 8: "IQX"},
+		BeiDou:  {1: "DPXSLZIQ", 2: "IQX", 5: "DPX", 6: "IQXDPZ", 7: "IQXDPZ", 8: "DPX"},
+		QZSS:    {1: "CSLXZBE", 2: "SLX", 5: "IQXDPZ", 6: "SLXEZ"},
+		IRNSS:   {5: "ABCX", 9: "ABCX"},
+		SBAS:    {1: "C", 5: "IQX"},
+	}
+
+	// rinex2Bands are the frequency bands of each satellite system in RINEX 2
+	rinex2Bands map[SatelliteSystem]string = map[SatelliteSystem]string{
+		GPS:     "125",
+		GLONASS: "12",
+		Galileo: "15678",
+		SBAS:    "15",
+	}
+)
+
+// ParseObservationCode parses a RINEX 3 observation code such as C1C, or a
+// RINEX 2 code such as P2.
+func ParseObservationCode(s string) (code ObservationCode, err error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 || len(s) > 3 || !strings.ContainsRune(observationTypes, rune(s[0])) || s[1] < '0' || s[1] > '9' {
+		return code, fmt.Errorf("invalid observation code \"%s\"", s)
+	}
+
+	code = ObservationCode{Type: ObservationType(s[0]), Band: int(s[1] - '0')}
+	if len(s) == 3 {
+		code.Attribute = s[2]
+		if (code.Attribute < 'A' || code.Attribute > 'Z') && (code.Attribute < '0' || code.Attribute > '9') {
+			return code, fmt.Errorf("invalid observation code \"%s\"", s)
+		}
+	}
+	return code, nil
+}
+
+func (t ObservationType) String() string {
+	return string(t)
+}
+
+// String returns the observation code as written in RINEX files, e.g. L1C.
+func (c ObservationCode) String() string {
+	if c.Type == 0 {
+		return ""
+	}
+	s := fmt.Sprintf("%c%d", c.Type, c.Band)
+	if c.Attribute != 0 {
+		s += string(c.Attribute)
+	}
+	return s
+}
+
+// Validate returns an error if the observation code isn't valid for the
+// satellite system in the given RINEX version. RINEX 3 codes must have an
+// attribute which is defined for the frequency band, while RINEX 2 codes
+// have no attribute.
+func (c ObservationCode) Validate(system SatelliteSystem, version float64) error {
+	if err := system.Validate(version); err != nil {
+		return err
+	}
+
+	if version < 3 {
+		if c.Attribute != 0 || c.Type == ChannelNumber || !strings.ContainsRune(rinex2Bands[system], rune('0'+c.Band)) {
+			return fmt.Errorf("invalid RINEX 2 %s observation code \"%s\"", system.Name(), c)
+		}
+		return nil
+	}
+
+	attributes, ok := bandAttributes[system][c.Band]
+	if system == BeiDou && c.Band == 1 {
+		// B1 was band 1 in 3.02, then band 2 from 3.03, with band 1 being B1C
+		// from 3.04
+		switch {
+		case version < 3.03:
+			attributes = "IQX"
+		case version < 3.04:
+			ok = false
+		default:
+			attributes = "DPXSLZ"
+		}
+	}
+	switch {
+	case c.Type == PCode:
+		return fmt.Errorf("invalid RINEX 3 observation code \"%s\"", c)
+	case !ok:
+		return fmt.Errorf("invalid %s frequency band in observation code \"%s\"", system.Name(), c)
+	case c.Type == ChannelNumber && c.Attribute == 0:
+		return nil // Receiver channel numbers have no attribute
+	case c.Attribute == 0 || !strings.ContainsRune(attributes, rune(c.Attribute)):
+		return fmt.Errorf("invalid %s attribute in observation code \"%s\"", system.Name(), c)
+	case c.Type == Pseudorange && c.Attribute == 'N':
+		return fmt.Errorf("codeless observation code \"%s\" can't be a pseudorange", c)
+	}
+	return nil
+}
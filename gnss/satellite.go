@@ -0,0 +1,158 @@
+// Package gnss defines the satellite systems, satellites and observation
+// codes used throughout RINEX files.
+package gnss
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SatelliteSystem is the single letter identifier of a satellite system, as
+// used in satellite numbers and SYS / # / OBS TYPES records
+type SatelliteSystem string
+
+const (
+	GPS     SatelliteSystem = "G"
+	GLONASS SatelliteSystem = "R"
+	Galileo SatelliteSystem = "E"
+	BeiDou  SatelliteSystem = "C"
+	QZSS    SatelliteSystem = "J"
+	IRNSS   SatelliteSystem = "I"
+	SBAS    SatelliteSystem = "S"
+	Mixed   SatelliteSystem = "M" // Only valid for a file, not a satellite
+)
+
+var (
+	satelliteSystemNames map[SatelliteSystem]string = map[SatelliteSystem]string{
+		GPS:     "GPS",
+		GLONASS: "GLONASS",
+		Galileo: "GALILEO",
+		BeiDou:  "BEIDOU",
+		QZSS:    "QZSS",
+		IRNSS:   "IRNSS",
+		SBAS:    "SBAS PAYLOAD",
+		Mixed:   "MIXED",
+	}
+
+	// satelliteSystemVersions are the first RINEX versions to support each
+	// satellite system
+	satelliteSystemVersions map[SatelliteSystem]float64 = map[SatelliteSystem]float64{
+		GPS:     2,
+		GLONASS: 2,
+		SBAS:    2.1,
+		Galileo: 2.1,
+		Mixed:   2,
+		BeiDou:  3.01,
+		QZSS:    3.01,
+		IRNSS:   3.03,
+	}
+
+	// Satellite numbers of each system range from 1 unless given by
+	// minSatelliteNumbers, with SBAS satellites numbered by their PRN - 100
+	minSatelliteNumbers map[SatelliteSystem]int = map[SatelliteSystem]int{SBAS: 20}
+	maxSatelliteNumbers map[SatelliteSystem]int = map[SatelliteSystem]int{
+		GPS:     32,
+		GLONASS: 27,
+		Galileo: 36,
+		BeiDou:  63,
+		QZSS:    10,
+		IRNSS:   14,
+		SBAS:    58,
+	}
+)
+
+// ParseSatelliteSystem parses a satellite system letter.
+func ParseSatelliteSystem(s string) (SatelliteSystem, error) {
+	system := SatelliteSystem(strings.ToUpper(strings.TrimSpace(s)))
+	if _, ok := satelliteSystemNames[system]; !ok {
+		return system, fmt.Errorf("invalid satellite system \"%s\"", s)
+	}
+	return system, nil
+}
+
+func (s SatelliteSystem) String() string {
+	return string(s)
+}
+
+// Name returns the name of the satellite system used in the RINEX VERSION /
+// TYPE record, such as GPS or MIXED.
+func (s SatelliteSystem) Name() string {
+	return satelliteSystemNames[s]
+}
+
+// Validate returns an error if the satellite system isn't supported by the
+// given RINEX version.
+func (s SatelliteSystem) Validate(version float64) error {
+	first, ok := satelliteSystemVersions[s]
+	if !ok {
+		return fmt.Errorf("invalid satellite system \"%s\"", s)
+	}
+	if version < first {
+		return fmt.Errorf("satellite system %s is not supported before RINEX %.2f", s.Name(), first)
+	}
+	return nil
+}
+
+// SatelliteID identifies a satellite by its system and number, such as G05
+// or R24. SBAS satellites are numbered by their PRN - 100, so PRN 120 is S20.
+type SatelliteID struct {
+	System SatelliteSystem
+	Number int
+}
+
+// ParseSatelliteID parses a satellite number such as G05, with a number
+// which may be padded with a space (G 5). An SBAS PRN may also be given in
+// full, with S120 being parsed as S20.
+func ParseSatelliteID(s string) (id SatelliteID, err error) {
+	if len(s) < 2 {
+		return id, fmt.Errorf("invalid satellite \"%s\"", s)
+	}
+	if id.System, err = ParseSatelliteSystem(s[:1]); err != nil || id.System == Mixed {
+		return id, fmt.Errorf("invalid satellite \"%s\"", s)
+	}
+	if id.Number, err = strconv.Atoi(strings.TrimSpace(s[1:])); err != nil || id.Number < 0 {
+		return id, fmt.Errorf("invalid satellite \"%s\"", s)
+	}
+	if id.System == SBAS && id.Number >= 100 {
+		id.Number -= 100
+	}
+	return id, nil
+}
+
+// String returns the satellite as written in RINEX 3 files, e.g. G05.
+func (id SatelliteID) String() string {
+	return fmt.Sprintf("%s%02d", id.System, id.Number)
+}
+
+// PRN returns the pseudo-random noise code number of the satellite, which is
+// offset from the satellite number for SBAS (by 100) and QZSS (by 192).
+func (id SatelliteID) PRN() int {
+	switch id.System {
+	case SBAS:
+		return id.Number + 100
+	case QZSS:
+		return id.Number + 192
+	default:
+		return id.Number
+	}
+}
+
+// Validate returns an error if the satellite system isn't supported by the
+// given RINEX version, or the number is out of the range of the system.
+func (id SatelliteID) Validate(version float64) error {
+	if id.System == Mixed {
+		return fmt.Errorf("invalid satellite \"%s\"", id)
+	}
+	if err := id.System.Validate(version); err != nil {
+		return err
+	}
+	min := minSatelliteNumbers[id.System]
+	if min == 0 {
+		min = 1
+	}
+	if id.Number < min || id.Number > maxSatelliteNumbers[id.System] {
+		return fmt.Errorf("invalid %s satellite number %d", id.System.Name(), id.Number)
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package gnss
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TimeSystem is the three letter identifier of a time system, as used in the
+// TIME OF FIRST OBS record
+type TimeSystem string
+
+const (
+	GPSTime     TimeSystem = "GPS"
+	GLONASSTime TimeSystem = "GLO" // UTC(SU) + 3 hours, but RINEX uses UTC(SU) itself
+	GalileoTime TimeSystem = "GAL"
+	BeiDouTime  TimeSystem = "BDT"
+	QZSSTime    TimeSystem = "QZS"
+	IRNSSTime   TimeSystem = "IRN"
+	UTC         TimeSystem = "UTC"
+)
+
+var (
+	// satelliteTimeSystems are the time systems of each satellite system,
+	// which are the default time systems of single system files
+	satelliteTimeSystems map[SatelliteSystem]TimeSystem = map[SatelliteSystem]TimeSystem{
+		GPS:     GPSTime,
+		GLONASS: GLONASSTime,
+		Galileo: GalileoTime,
+		BeiDou:  BeiDouTime,
+		QZSS:    QZSSTime,
+		IRNSS:   IRNSSTime,
+		SBAS:    GPSTime,
+	}
+
+	// GPSEpoch is the start of GPS week 0, in GPS time
+	GPSEpoch time.Time = time.Date(1980, time.January, 6, 0, 0, 0, 0, time.UTC)
+	// BeiDouEpoch is the start of BDS week 0, in BeiDou time
+	BeiDouEpoch time.Time = time.Date(2006, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// TimeResolution is the resolution of Time, being that of RINEX epochs
+const TimeResolution = 100 * time.Nanosecond
+
+// ParseTimeSystem parses a time system identifier such as GPS.
+func ParseTimeSystem(s string) (TimeSystem, error) {
+	system := TimeSystem(s)
+	switch system {
+	case GPSTime, GLONASSTime, GalileoTime, BeiDouTime, QZSSTime, IRNSSTime, UTC:
+		return system, nil
+	}
+	return system, fmt.Errorf("invalid time system \"%s\"", s)
+}
+
+// TimeSystem returns the time system of the satellite system, or an empty
+// TimeSystem for Mixed.
+func (s SatelliteSystem) TimeSystem() TimeSystem {
+	return satelliteTimeSystems[s]
+}
+
+// Time is an epoch in a GNSS time system, with a resolution of 100 ns. It
+// holds the reading of a clock in the time system, so no leap seconds are
+// involved in its arithmetic.
+type Time struct {
+	clock  time.Time // Always in the UTC location
+	system TimeSystem
+}
+
+// NewTime returns the Time whose clock reading in the time system is the date
+// and time of t (in its location), rounded to 100 ns.
+func NewTime(t time.Time, system TimeSystem) Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	clock := time.Date(year, month, day, hour, min, sec, t.Nanosecond(), time.UTC)
+	return Time{clock: clock.Round(TimeResolution), system: system}
+}
+
+// Date returns the Time of a date and time in the time system, with seconds
+// being rounded to 100 ns.
+func Date(system TimeSystem, year int, month time.Month, day, hour, min int, sec float64) Time {
+	clock := time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	ticks := math.Round(sec * float64(time.Second/TimeResolution))
+	return Time{clock: clock.Add(time.Duration(ticks) * TimeResolution), system: system}
+}
+
+// GPSWeekTime returns the Time of a GPS week and time of week in seconds.
+func GPSWeekTime(week int, tow float64) Time {
+	return NewTime(GPSEpoch.AddDate(0, 0, 7*week), GPSTime).Add(time.Duration(math.Round(tow*1e7)) * TimeResolution)
+}
+
+// System returns the time system of the Time.
+func (t Time) System() TimeSystem {
+	return t.system
+}
+
+// GoTime returns the clock reading of the Time as a time.Time in the UTC
+// location, without any conversion between time systems.
+func (t Time) GoTime() time.Time {
+	return t.clock
+}
+
+// IsZero returns true for the zero Time, such as an unset TIME OF LAST OBS.
+func (t Time) IsZero() bool {
+	return t.clock.IsZero()
+}
+
+// Seconds returns the seconds of the minute, including the fraction.
+func (t Time) Seconds() float64 {
+	return float64(t.clock.Second()) + float64(t.clock.Nanosecond())/1e9
+}
+
+// DayOfYear returns the year and day of year of the Time, with 1 January
+// being day 1.
+func (t Time) DayOfYear() (year, day int) {
+	return t.clock.Year(), t.clock.YearDay()
+}
+
+// GPSWeek returns the GPS week and time of week in seconds of the clock
+// reading of the Time, which should be in GPS time for them to be meaningful.
+func (t Time) GPSWeek() (week int, tow float64) {
+	elapsed := t.clock.Sub(GPSEpoch)
+	weekDuration := 7 * 24 * time.Hour
+	week = int(elapsed / weekDuration)
+	if elapsed < 0 && elapsed%weekDuration != 0 {
+		week--
+	}
+	return week, (elapsed - time.Duration(week)*weekDuration).Seconds()
+}
+
+// Add returns the Time plus d, rounded to 100 ns.
+func (t Time) Add(d time.Duration) Time {
+	return Time{clock: t.clock.Add(d).Round(TimeResolution), system: t.system}
+}
+
+// Sub returns the duration t - u, comparing their clock readings, so the
+// times should be in the same time system.
+func (t Time) Sub(u Time) time.Duration {
+	return t.clock.Sub(u.clock)
+}
+
+// Before returns true if the clock reading of t is before that of u.
+func (t Time) Before(u Time) bool {
+	return t.clock.Before(u.clock)
+}
+
+// After returns true if the clock reading of t is after that of u.
+func (t Time) After(u Time) bool {
+	return t.clock.After(u.clock)
+}
+
+// Equal returns true if t and u have the same clock reading and time system.
+func (t Time) Equal(u Time) bool {
+	return t.clock.Equal(u.clock) && t.system == u.system
+}
+
+// String formats the Time with its time system, e.g.
+// 2018-11-24 00:00:30.0000000 GPS.
+func (t Time) String() string {
+	s := t.clock.Format("2006-01-02 15:04:05.0000000")
+	if t.system != "" {
+		s += " " + string(t.system)
+	}
+	return s
+}
@@ -0,0 +1,185 @@
+// Package gnsstime converts times between the time systems used in RINEX
+// files, using a table of leap seconds which can be overridden by LEAP
+// SECONDS records, and the polynomial corrections between time systems of
+// TIME SYSTEM CORR records.
+//
+// GPS, Galileo, QZSS and IRNSS time are aligned, with BeiDou time being 14
+// seconds behind them, and UTC being behind by the leap seconds. RINEX
+// GLONASS times (GLO) are UTC(SU), while GLONASS system time is 3 hours
+// ahead of them, as given by GLONASSOffset.
+package gnsstime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-gnss/rinex/gnss"
+)
+
+const (
+	// BeiDouOffset is GPS time - BeiDou time
+	BeiDouOffset = 14 * time.Second
+	// GLONASSOffset is GLONASS system time - UTC(SU)
+	GLONASSOffset = 3 * time.Hour
+)
+
+var (
+	correctionSystems map[string]gnss.TimeSystem = map[string]gnss.TimeSystem{
+		"GP": gnss.GPSTime,
+		"GA": gnss.GalileoTime,
+		"GL": gnss.GLONASSTime,
+		"BD": gnss.BeiDouTime,
+		"QZ": gnss.QZSSTime,
+		"IR": gnss.IRNSSTime,
+		"UT": gnss.UTC,
+	}
+)
+
+// Correction is the fractional difference between two time systems, From -
+// To = A0 + A1 * (t - Reference) seconds, on top of the whole seconds which
+// separate them.
+type Correction struct {
+	From      gnss.TimeSystem
+	To        gnss.TimeSystem
+	A0        float64
+	A1        float64
+	Reference gnss.Time // A zero Reference ignores A1
+}
+
+// NewCorrection returns the Correction of a TIME SYSTEM CORR record of the
+// given type, such as GPUT (GPS - UTC) or GAGP (GAL - GPS), with a reference
+// time given as seconds into a GPS week, or a BeiDou week for BDUT.
+func NewCorrection(correctionType string, a0, a1 float64, referenceTime, referenceWeek int) (c Correction, err error) {
+	var fromOK, toOK bool
+	if len(correctionType) == 4 {
+		c.From, fromOK = correctionSystems[correctionType[:2]]
+		c.To, toOK = correctionSystems[correctionType[2:]]
+	}
+	if !fromOK || !toOK {
+		return c, fmt.Errorf("unsupported time system correction \"%s\"", correctionType)
+	}
+
+	c.A0, c.A1 = a0, a1
+	if referenceTime != 0 || referenceWeek != 0 {
+		epoch, system := gnss.GPSEpoch, gnss.GPSTime
+		if c.From == gnss.BeiDouTime {
+			epoch, system = gnss.BeiDouEpoch, gnss.BeiDouTime
+		}
+		reference := epoch.AddDate(0, 0, 7*referenceWeek).Add(time.Duration(referenceTime) * time.Second)
+		c.Reference = gnss.NewTime(reference, system)
+	}
+	return c, nil
+}
+
+// At returns From - To at a time.
+func (c Correction) At(t gnss.Time) time.Duration {
+	return time.Duration(c.Seconds(t) * float64(time.Second))
+}
+
+// Seconds returns From - To at a time in seconds, without rounding to
+// nanoseconds.
+func (c Correction) Seconds(t gnss.Time) float64 {
+	seconds := c.A0
+	if !c.Reference.IsZero() {
+		seconds += c.A1 * t.Sub(c.Reference).Seconds()
+	}
+	return seconds
+}
+
+// Converter converts times between time systems using its LeapSeconds, and
+// any Corrections between the two time systems.
+type Converter struct {
+	LeapSeconds LeapSecondTable
+	Corrections []Correction
+}
+
+// NewConverter returns a Converter using DefaultLeapSeconds and no
+// corrections.
+func NewConverter() *Converter {
+	return &Converter{LeapSeconds: DefaultLeapSeconds}
+}
+
+// Convert returns a time in another time system, using a Converter with
+// DefaultLeapSeconds and no corrections.
+func Convert(t gnss.Time, system gnss.TimeSystem) (gnss.Time, error) {
+	return NewConverter().Convert(t, system)
+}
+
+// Convert returns a time in another time system. The time is converted
+// through GPS time, with the whole seconds between the time systems being
+// given by the leap seconds and BeiDouOffset, and then the first Correction
+// between the two time systems (in either direction) being applied.
+//
+// The converted time is rounded to gnss.TimeResolution, which loses
+// corrections of less than 50 ns - use ConvertPrecise to keep them.
+func (c *Converter) Convert(t gnss.Time, system gnss.TimeSystem) (gnss.Time, error) {
+	converted, _, err := c.ConvertPrecise(t, system)
+	return converted, err
+}
+
+// ConvertPrecise is Convert, also returning the residual of the correction
+// in seconds which is lost by rounding to gnss.TimeResolution, so that the
+// exact converted time is the converted time plus the residual.
+func (c *Converter) ConvertPrecise(t gnss.Time, system gnss.TimeSystem) (converted gnss.Time, residual float64, err error) {
+	if t.System() == system {
+		return t, 0, nil
+	}
+
+	gps, err := c.toGPS(t)
+	if err != nil {
+		return t, 0, err
+	}
+	converted, err = c.fromGPS(gps, system)
+	if err != nil {
+		return t, 0, err
+	}
+
+	for _, correction := range c.Corrections {
+		var seconds float64
+		switch {
+		case correction.From == t.System() && correction.To == system:
+			seconds = -correction.Seconds(t)
+		case correction.From == system && correction.To == t.System():
+			seconds = correction.Seconds(t)
+		default:
+			continue
+		}
+		corrected := converted.Add(time.Duration(seconds * float64(time.Second)))
+		return corrected, seconds - corrected.Sub(converted).Seconds(), nil
+	}
+	return converted, 0, nil
+}
+
+// toGPS returns the clock reading in GPS time of a time
+func (c *Converter) toGPS(t gnss.Time) (time.Time, error) {
+	clock := t.GoTime()
+	switch t.System() {
+	case gnss.GPSTime, gnss.GalileoTime, gnss.QZSSTime, gnss.IRNSSTime:
+		return clock, nil
+	case gnss.BeiDouTime:
+		return clock.Add(BeiDouOffset), nil
+	case gnss.UTC, gnss.GLONASSTime:
+		return clock.Add(time.Duration(c.LeapSeconds.AtUTC(clock)) * time.Second), nil
+	}
+	return clock, fmt.Errorf("can't convert from time system \"%s\"", t.System())
+}
+
+// fromGPS returns the time in a time system of a clock reading in GPS time
+func (c *Converter) fromGPS(gps time.Time, system gnss.TimeSystem) (gnss.Time, error) {
+	switch system {
+	case gnss.GPSTime, gnss.GalileoTime, gnss.QZSSTime, gnss.IRNSSTime:
+		return gnss.NewTime(gps, system), nil
+	case gnss.BeiDouTime:
+		return gnss.NewTime(gps.Add(-BeiDouOffset), system), nil
+	case gnss.UTC, gnss.GLONASSTime:
+		return gnss.NewTime(gps.Add(-time.Duration(c.LeapSeconds.AtGPS(gps))*time.Second), system), nil
+	}
+	return gnss.Time{}, fmt.Errorf("can't convert to time system \"%s\"", system)
+}
+
+// GLONASSSystemTime returns the GLONASS system time (UTC(SU) + 3 hours) of a
+// time.
+func (c *Converter) GLONASSSystemTime(t gnss.Time) (time.Time, error) {
+	glo, err := c.Convert(t, gnss.GLONASSTime)
+	return glo.GoTime().Add(GLONASSOffset), err
+}
@@ -0,0 +1,140 @@
+package gnsstime_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/gnsstime"
+)
+
+func TestConvert(t *testing.T) {
+	gps := gnss.Date(gnss.GPSTime, 2018, time.November, 24, 0, 0, 0)
+	tests := map[gnss.TimeSystem]time.Time{
+		gnss.GPSTime:     time.Date(2018, time.November, 24, 0, 0, 0, 0, time.UTC),
+		gnss.GalileoTime: time.Date(2018, time.November, 24, 0, 0, 0, 0, time.UTC),
+		gnss.QZSSTime:    time.Date(2018, time.November, 24, 0, 0, 0, 0, time.UTC),
+		gnss.IRNSSTime:   time.Date(2018, time.November, 24, 0, 0, 0, 0, time.UTC),
+		gnss.BeiDouTime:  time.Date(2018, time.November, 23, 23, 59, 46, 0, time.UTC),
+		gnss.UTC:         time.Date(2018, time.November, 23, 23, 59, 42, 0, time.UTC),
+		gnss.GLONASSTime: time.Date(2018, time.November, 23, 23, 59, 42, 0, time.UTC),
+	}
+	for system, expected := range tests {
+		converted, err := gnsstime.Convert(gps, system)
+		if err != nil {
+			t.Errorf("failed to convert to %s: %v", system, err)
+			continue
+		}
+		if converted.System() != system || !converted.GoTime().Equal(expected) {
+			t.Errorf("incorrect conversion to %s: %s", system, converted)
+		}
+
+		// And back again
+		if back, err := gnsstime.Convert(converted, gnss.GPSTime); err != nil || !back.Equal(gps) {
+			t.Errorf("incorrect conversion from %s: %s (%v)", system, back, err)
+		}
+	}
+
+	if _, err := gnsstime.Convert(gps, "TAI"); err == nil {
+		t.Error("conversion to an unknown time system should fail")
+	}
+	if _, err := gnsstime.Convert(gnss.NewTime(gps.GoTime(), ""), gnss.UTC); err == nil {
+		t.Error("conversion from a time without a time system should fail")
+	}
+
+	glonass, err := gnsstime.NewConverter().GLONASSSystemTime(gps)
+	if err != nil || !glonass.Equal(time.Date(2018, time.November, 24, 2, 59, 42, 0, time.UTC)) {
+		t.Errorf("incorrect GLONASS system time: %s (%v)", glonass, err)
+	}
+}
+
+func TestLeapSeconds(t *testing.T) {
+	table := gnsstime.DefaultLeapSeconds
+	if offset := table.AtUTC(time.Date(1980, time.June, 1, 0, 0, 0, 0, time.UTC)); offset != 0 {
+		t.Errorf("incorrect leap seconds before the first: %d", offset)
+	}
+	if offset := table.AtUTC(time.Date(2016, time.December, 31, 23, 59, 59, 0, time.UTC)); offset != 17 {
+		t.Errorf("incorrect leap seconds before the end of 2016: %d", offset)
+	}
+	if offset := table.AtUTC(time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)); offset != 18 {
+		t.Errorf("incorrect leap seconds from 2017: %d", offset)
+	}
+	if offset := table.AtGPS(time.Date(2017, time.January, 1, 0, 0, 17, 0, time.UTC)); offset != 17 {
+		t.Errorf("incorrect leap seconds before the end of 2016 in GPS time: %d", offset)
+	}
+	if offset := table.AtGPS(time.Date(2017, time.January, 1, 0, 0, 18, 0, time.UTC)); offset != 18 {
+		t.Errorf("incorrect leap seconds from 2017 in GPS time: %d", offset)
+	}
+
+	// Conversions across the leap second
+	utc := gnss.Date(gnss.UTC, 2016, time.December, 31, 23, 59, 59)
+	for _, expected := range []time.Time{
+		time.Date(2017, time.January, 1, 0, 0, 16, 0, time.UTC),
+		time.Date(2017, time.January, 1, 0, 0, 18, 0, time.UTC),
+	} {
+		if gps, err := gnsstime.Convert(utc, gnss.GPSTime); err != nil || !gps.GoTime().Equal(expected) {
+			t.Errorf("incorrect GPS time of %s: %s (%v)", utc, gps, err)
+		}
+		utc = utc.Add(time.Second)
+	}
+
+	// A new leap second doesn't change the default table
+	next := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	updated := table.With(gnsstime.LeapSecond{Time: next, Offset: 19})
+	if updated.AtUTC(next) != 19 || table.AtUTC(next) != 18 || len(updated.LeapSeconds) != len(table.LeapSeconds)+1 {
+		t.Errorf("incorrect leap seconds after adding one: %d %d", updated.AtUTC(next), table.AtUTC(next))
+	}
+	converter := gnsstime.NewConverter()
+	converter.LeapSeconds = updated
+	if converted, _ := converter.Convert(gnss.NewTime(next, gnss.UTC), gnss.GPSTime); converted.GoTime().Second() != 19 {
+		t.Errorf("incorrect GPS time with the new leap second: %s", converted)
+	}
+}
+
+func TestCorrections(t *testing.T) {
+	correction, err := gnsstime.NewCorrection("GPUT", 1e-6, 1e-9, 100, 2028)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	reference := gnss.GPSWeekTime(2028, 100)
+	if correction.From != gnss.GPSTime || correction.To != gnss.UTC || !correction.Reference.Equal(reference) {
+		t.Errorf("incorrect correction: %+v", correction)
+	}
+	if at := correction.At(reference.Add(1000 * time.Second)); at != 2*time.Microsecond {
+		t.Errorf("incorrect correction after 1000 seconds: %s", at)
+	}
+
+	converter := gnsstime.NewConverter()
+	converter.Corrections = []gnsstime.Correction{correction}
+	utc, err := converter.Convert(reference, gnss.UTC)
+	if err != nil || !utc.GoTime().Equal(reference.GoTime().Add(-18*time.Second-time.Microsecond)) {
+		t.Errorf("incorrect corrected UTC: %s (%v)", utc, err)
+	}
+	if gps, err := converter.Convert(utc, gnss.GPSTime); err != nil || !gps.Equal(reference) {
+		t.Errorf("incorrect corrected GPS time: %s (%v)", gps, err)
+	}
+
+	// Corrections of nanoseconds are kept by ConvertPrecise as a residual
+	converter.Corrections[0], _ = gnsstime.NewCorrection("GPUT", -9.3e-9, 0, 0, 0)
+	utc, residual, err := converter.ConvertPrecise(reference, gnss.UTC)
+	if err != nil || !utc.GoTime().Equal(reference.GoTime().Add(-18*time.Second)) || math.Abs(residual-9.3e-9) > 1e-15 {
+		t.Errorf("incorrect precisely corrected UTC: %s + %g (%v)", utc, residual, err)
+	}
+	converter.Corrections[0], _ = gnsstime.NewCorrection("GPUT", -1.23e-7, 0, 0, 0)
+	utc, residual, err = converter.ConvertPrecise(reference, gnss.UTC)
+	if err != nil || !utc.GoTime().Equal(reference.GoTime().Add(-18*time.Second+100*time.Nanosecond)) || math.Abs(residual-2.3e-8) > 1e-15 {
+		t.Errorf("incorrect precisely corrected UTC: %s + %g (%v)", utc, residual, err)
+	}
+	if _, residual, _ := gnsstime.NewConverter().ConvertPrecise(reference, gnss.UTC); residual != 0 {
+		t.Errorf("incorrect residual without a correction: %g", residual)
+	}
+
+	bds, err := gnsstime.NewCorrection("BDUT", 0, 0, 0, 1)
+	if err != nil || bds.Reference.System() != gnss.BeiDouTime || !bds.Reference.GoTime().Equal(time.Date(2006, time.January, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("incorrect BDUT reference time: %s (%v)", bds.Reference, err)
+	}
+	if _, err := gnsstime.NewCorrection("SBUT", 0, 0, 0, 0); err == nil {
+		t.Error("SBUT corrections should be unsupported")
+	}
+}
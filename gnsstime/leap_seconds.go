@@ -0,0 +1,93 @@
+package gnsstime
+
+import (
+	"sort"
+	"time"
+)
+
+// LeapSecond is the offset between GPS time and UTC (GPS - UTC) in seconds
+// from a UTC time onwards
+type LeapSecond struct {
+	Time   time.Time
+	Offset int
+}
+
+// LeapSecondTable is a table of leap seconds in time order, with a Version
+// identifying the leap second announcements it includes
+type LeapSecondTable struct {
+	Version     string
+	LeapSeconds []LeapSecond
+}
+
+// DefaultLeapSeconds is the table of leap seconds as of the leap second at
+// the end of 2016, which no leap second has followed at the time of writing.
+// Tables which are out of date can be corrected with LEAP SECONDS records.
+var DefaultLeapSeconds = LeapSecondTable{
+	Version: "2017-01-01",
+	LeapSeconds: []LeapSecond{
+		{Time: utcDate(1981, time.July, 1), Offset: 1},
+		{Time: utcDate(1982, time.July, 1), Offset: 2},
+		{Time: utcDate(1983, time.July, 1), Offset: 3},
+		{Time: utcDate(1985, time.July, 1), Offset: 4},
+		{Time: utcDate(1988, time.January, 1), Offset: 5},
+		{Time: utcDate(1990, time.January, 1), Offset: 6},
+		{Time: utcDate(1991, time.January, 1), Offset: 7},
+		{Time: utcDate(1992, time.July, 1), Offset: 8},
+		{Time: utcDate(1993, time.July, 1), Offset: 9},
+		{Time: utcDate(1994, time.July, 1), Offset: 10},
+		{Time: utcDate(1996, time.January, 1), Offset: 11},
+		{Time: utcDate(1997, time.July, 1), Offset: 12},
+		{Time: utcDate(1999, time.January, 1), Offset: 13},
+		{Time: utcDate(2006, time.January, 1), Offset: 14},
+		{Time: utcDate(2009, time.January, 1), Offset: 15},
+		{Time: utcDate(2012, time.July, 1), Offset: 16},
+		{Time: utcDate(2015, time.July, 1), Offset: 17},
+		{Time: utcDate(2017, time.January, 1), Offset: 18},
+	},
+}
+
+func utcDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// AtUTC returns GPS - UTC in seconds at a UTC time, which is 0 before the
+// first leap second.
+func (t LeapSecondTable) AtUTC(utc time.Time) int {
+	i := sort.Search(len(t.LeapSeconds), func(i int) bool {
+		return t.LeapSeconds[i].Time.After(utc)
+	})
+	if i == 0 {
+		return 0
+	}
+	return t.LeapSeconds[i-1].Offset
+}
+
+// AtGPS returns GPS - UTC in seconds at a GPS time, whose clock reading is
+// given as a time.Time.
+func (t LeapSecondTable) AtGPS(gps time.Time) int {
+	i := sort.Search(len(t.LeapSeconds), func(i int) bool {
+		ls := t.LeapSeconds[i]
+		return ls.Time.Add(time.Duration(ls.Offset) * time.Second).After(gps)
+	})
+	if i == 0 {
+		return 0
+	}
+	return t.LeapSeconds[i-1].Offset
+}
+
+// With returns a copy of the table including a leap second, which replaces
+// any leap second at the same time. Leap seconds are only ever added to a
+// copy, so DefaultLeapSeconds can be safely overridden.
+func (t LeapSecondTable) With(ls LeapSecond) LeapSecondTable {
+	result := LeapSecondTable{Version: t.Version}
+	for _, existing := range t.LeapSeconds {
+		if !existing.Time.Equal(ls.Time) {
+			result.LeapSeconds = append(result.LeapSeconds, existing)
+		}
+	}
+	result.LeapSeconds = append(result.LeapSeconds, ls)
+	sort.Slice(result.LeapSeconds, func(i, j int) bool {
+		return result.LeapSeconds[i].Time.Before(result.LeapSeconds[j].Time)
+	})
+	return result
+}
@@ -1,3 +1,5 @@
 module github.com/go-gnss/rinex
 
 go 1.14
+
+require gopkg.in/yaml.v2 v2.4.0
@@ -0,0 +1,177 @@
+// Package hatanaka implements Compact RINEX (Hatanaka compression) as
+// described in "Compact RINEX format and tools" (Y. Hatanaka), for both
+// CRINEX 1.0 (RINEX 2 observation files) and CRINEX 3.0 (RINEX 3 and 4).
+//
+// Observation values are stored as integers in units of the last decimal
+// place, differenced up to the arc order given when the arc is initialised
+// ("3&123456"). Epoch lines and LLI/SSI flags are stored as text differences
+// against the previous epoch, where a space means the character is unchanged
+// and "&" means the character has changed to a space.
+package hatanaka
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	VersionLabel = "CRINEX VERS   / TYPE"
+	ProgramLabel = "CRINEX PROG / DATE"
+
+	maxArcOrder = 9
+)
+
+// IsCompact reports whether the data in r begins with a CRINEX VERS / TYPE
+// line, without consuming any of it.
+func IsCompact(r *bufio.Reader) bool {
+	line, _ := r.Peek(80)
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	return bytes.Contains(line, []byte("CRINEX VERS"))
+}
+
+// field is the differencing state for a single value, such as one
+// observation type for one satellite or the receiver clock offset
+type field struct {
+	arcOrder int
+	order    int // -1 when there is no active arc
+	u        [maxArcOrder + 1]int64
+}
+
+func newField() field {
+	return field{order: -1}
+}
+
+// decode recovers a value from its compressed representation, which is
+// either an arc initialisation "N&value" or an Nth order difference
+func (f *field) decode(s string) (int64, error) {
+	if len(s) > 1 && s[1] == '&' {
+		order, err := strconv.Atoi(s[:1])
+		if err != nil {
+			return 0, err
+		}
+		value, err := strconv.ParseInt(s[2:], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		f.arcOrder, f.order, f.u[0] = order, 0, value
+		return value, nil
+	}
+
+	if f.order < 0 {
+		return 0, fmt.Errorf("difference \"%s\" without arc initialisation", s)
+	}
+	diff, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if f.order < f.arcOrder {
+		f.order++
+	}
+	f.u[f.order] = diff
+	for k := f.order; k > 0; k-- {
+		f.u[k-1] += f.u[k]
+	}
+	return f.u[0], nil
+}
+
+// encode returns the compressed representation of value, initialising a new
+// arc of the given order if there isn't an active arc
+func (f *field) encode(value int64, arcOrder int) string {
+	if f.order < 0 {
+		f.arcOrder, f.order, f.u[0] = arcOrder, 0, value
+		return fmt.Sprintf("%d&%d", arcOrder, value)
+	}
+
+	var u [maxArcOrder + 1]int64
+	u[0] = value
+	order := f.order
+	if order < f.arcOrder {
+		order++
+	}
+	for k := 1; k <= order; k++ {
+		u[k] = u[k-1] - f.u[k-1]
+	}
+	f.order, f.u = order, u
+	return strconv.FormatInt(u[order], 10)
+}
+
+// applyDiff reconstructs a line from the previous line and a text difference
+func applyDiff(old, diff string) string {
+	line := []byte(old)
+	for len(line) < len(diff) {
+		line = append(line, ' ')
+	}
+	for i := 0; i < len(diff); i++ {
+		switch diff[i] {
+		case ' ':
+		case '&':
+			line[i] = ' '
+		default:
+			line[i] = diff[i]
+		}
+	}
+	return string(line)
+}
+
+// textDiff returns the text difference to reconstruct line from old, with
+// trailing unchanged characters removed
+func textDiff(old, line string) string {
+	length := len(line)
+	if len(old) > length {
+		length = len(old)
+	}
+
+	diff := make([]byte, length)
+	for i := range diff {
+		o, n := byte(' '), byte(' ')
+		if i < len(old) {
+			o = old[i]
+		}
+		if i < len(line) {
+			n = line[i]
+		}
+		switch {
+		case o == n:
+			diff[i] = ' '
+		case n == ' ':
+			diff[i] = '&'
+		default:
+			diff[i] = n
+		}
+	}
+	return strings.TrimRight(string(diff), " ")
+}
+
+// parseFixed parses a fixed point number with the given number of decimals,
+// such as "-1234.567", into an integer in units of the last decimal place
+func parseFixed(s string, decimals int) (int64, error) {
+	s = strings.TrimSpace(s)
+	point := strings.IndexByte(s, '.')
+	if point == -1 || len(s)-point-1 != decimals {
+		return 0, fmt.Errorf("\"%s\" doesn't have %d decimals", s, decimals)
+	}
+	return strconv.ParseInt(s[:point]+s[point+1:], 10, 64)
+}
+
+// formatFixed formats value (in units of the last of the given decimals)
+// as a fixed point number right aligned to width
+func formatFixed(value int64, decimals, width int) string {
+	sign := ""
+	if value < 0 {
+		sign, value = "-", -value
+	}
+	pow := int64(1)
+	for i := 0; i < decimals; i++ {
+		pow *= 10
+	}
+	s := fmt.Sprintf("%s%d.%0*d", sign, value/pow, decimals, value%pow)
+	if len(s) < width {
+		s = strings.Repeat(" ", width-len(s)) + s
+	}
+	return s
+}
@@ -0,0 +1,184 @@
+package hatanaka_test
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-gnss/rinex/hatanaka"
+)
+
+var compactFixtures = map[string]string{
+	"../fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.crx": "../fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx",
+	"../fixtures/alby3280.18d":                           "../fixtures/alby3280.18o",
+}
+
+func TestReader(t *testing.T) {
+	for compact, expected := range compactFixtures {
+		file, err := os.Open(compact)
+		if err != nil {
+			t.Fatalf("failed to open %s", compact)
+		}
+		defer file.Close()
+
+		reader, err := hatanaka.NewReader(file)
+		if err != nil {
+			t.Fatal(err.Error())
+		}
+		decompressed, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("%s: %v", compact, err)
+		}
+
+		rinex, err := ioutil.ReadFile(expected)
+		if err != nil {
+			t.Fatalf("failed to read %s", expected)
+		}
+		if string(decompressed) != string(rinex) {
+			t.Errorf("%s: decompressed data does not match %s:\n%s", compact, expected, decompressed)
+		}
+	}
+}
+
+func TestReaderInvalidHeader(t *testing.T) {
+	file, err := os.Open("../fixtures/ALBY00AUS_R_20183280000_01D_30S_MO.rnx")
+	if err != nil {
+		t.Fatal("failed to open test observation file")
+	}
+	defer file.Close()
+
+	if _, err := hatanaka.NewReader(file); err == nil {
+		t.Error("expected error for RINEX file without CRINEX header")
+	}
+}
+
+// Epochs with satellites dropping in and out, missing observations, changing
+// flags, receiver clock offsets and an event with special records
+const rinex3Events = `     3.04           OBSERVATION DATA    M                   RINEX VERSION / TYPE
+G    3 C1C L1C S1C                                          SYS / # / OBS TYPES
+E    2 C1C L1C                                              SYS / # / OBS TYPES
+                                                            END OF HEADER
+> 2018 11 24 00 00  0.0000000  0  3      -0.000123456789
+G05  22107568.420 7 116174032.456 7        45.250
+G13  23456789.123 7 123265434.789 7        41.500
+E11  24567890.321 7 129100987.654 7
+> 2018 11 24 00 00 30.0000000  0  2      -0.000123456000
+G05  22108977.975 7 116211069.4661
+E11  24567368.787 8 129087283.984 8
+> 2018 11 24 00 00 45.0000000  4  1
+ANTENNA CHANGED                                             COMMENT
+> 2018 11 24 00 01  0.0000000  0  3
+G05  22110387.529 7 116248106.476 7        45.000
+G13        -0.123   123124694.109 6        40.750
+E11  24566847.252 7 129073580.314 7
+`
+
+func TestWriterRoundTrip(t *testing.T) {
+	inputs := map[string]string{"events": rinex3Events, "arcs": rinex3Arcs}
+	for _, rinex := range compactFixtures {
+		data, err := ioutil.ReadFile(rinex)
+		if err != nil {
+			t.Fatalf("failed to read %s", rinex)
+		}
+		inputs[rinex] = string(data)
+	}
+
+	for name, input := range inputs {
+		var compressed bytes.Buffer
+		if err := hatanaka.Compress(&compressed, strings.NewReader(input)); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		reader, err := hatanaka.NewReader(&compressed)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		decompressed, err := ioutil.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if string(decompressed) != input {
+			t.Errorf("%s: round trip does not match input:\n%s", name, decompressed)
+		}
+	}
+}
+
+// An observation file whose Compact RINEX is worked through by hand from the
+// CRINEX 3.0 specification below, rather than being produced by the Writer
+const rinex3Arcs = `     3.04           OBSERVATION DATA    G                   RINEX VERSION / TYPE
+G    3 C1C L1C S1C                                          SYS / # / OBS TYPES
+                                                            END OF HEADER
+> 2018 11 24 00 00  0.0000000  0  2      -0.000123456789
+G05  22107568.420 7 116174032.456 7        45.250
+G13  23456789.123 6 123265434.789 6        41.500
+> 2018 11 24 00 00 30.0000000  0  2      -0.000123456000
+G05  22108977.975 7 116211069.46617        45.250
+G13  23454110.969 6 123195064.449 6
+> 2018 11 24 00 01  0.0000000  0  2      -0.000123455000
+G05  22110387.529 7 116248106.476 7        45.000
+G13  23451432.815 5 123124694.109 6        40.750
+> 2018 11 24 00 01 30.0000000  0  1
+G05  22111797.083 7 116285143.486 7        45.000
+`
+
+// The epoch lines are text differences from the previous epoch line (with
+// the clock offset removed and the satellites appended), where & is a
+// character changed to a space. Clock offsets and observations start arcs
+// with 3&, and are then given as differences of increasing order up to the
+// 3rd, with a blank observation restarting its arc. The LLIs and signal
+// strengths of a satellite follow its observations, as a text difference.
+const crinex3Arcs = `3.0                 COMPACT RINEX FORMAT                    CRINEX VERS   / TYPE
+go-gnss/rinex                           24-Nov-18 00:00     CRINEX PROG / DATE
+     3.04           OBSERVATION DATA    G                   RINEX VERSION / TYPE
+G    3 C1C L1C S1C                                          SYS / # / OBS TYPES
+                                                            END OF HEADER
+> 2018 11 24 00 00  0.0000000  0  2      G05G13
+3&-123456789
+3&22107568420 3&116174032456 3&45250  7 7
+3&23456789123 3&123265434789 3&41500  6 6
+                   3
+789
+1409555 37037010 0   1
+-2678154 -70370340
+                 1 &
+211
+-1 0 -250   &
+0 0 3&40750  5
+                   3              1         &&&
+
+1 0 500
+`
+
+func TestWriterMatchesSpecification(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := hatanaka.NewWriter(&compressed)
+	writer.Date = time.Date(2018, time.November, 24, 0, 0, 0, 0, time.UTC)
+	if _, err := io.WriteString(writer, rinex3Arcs); err != nil {
+		t.Fatal(err.Error())
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err.Error())
+	}
+	if compressed.String() != crinex3Arcs {
+		t.Errorf("compressed data does not match:\n%s", compressed.String())
+	}
+
+	// Observations and clock offsets must have all of their decimals
+	for _, line := range []string{
+		"G05      22107568 7 116174032.456 7        45.250\n",
+		"G05   22107568.42 7 116174032.456 7        45.250\n",
+		"> 2018 11 24 00 01 30.0000000  0  1      -0.000123455\n",
+	} {
+		input := strings.Replace(rinex3Arcs, "G05  22111797.083 7 116285143.486 7        45.000\n", line, 1)
+		if strings.HasPrefix(line, ">") {
+			input = strings.Replace(rinex3Arcs, "> 2018 11 24 00 01 30.0000000  0  1\n", line, 1)
+		}
+		if err := hatanaka.Compress(ioutil.Discard, strings.NewReader(input)); err == nil {
+			t.Errorf("expected an error compressing %q", line)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package hatanaka
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-gnss/rinex/scanner"
+)
+
+// epochLayout describes the columns of the epoch line, which is the RINEX
+// epoch line with the receiver clock offset removed and all satellites
+// appended to the first line
+type epochLayout struct {
+	flag          int // Column of the epoch flag
+	numSatellites int // Start of the I3 number of satellites
+	satellites    int // Start of the satellite list
+	clock         int // Start of the receiver clock offset in RINEX
+	clockWidth    int
+	clockDecimals int
+}
+
+var (
+	crinex1Layout = epochLayout{28, 29, 32, 68, 12, 9}  // RINEX 2
+	crinex3Layout = epochLayout{31, 32, 41, 41, 15, 12} // RINEX 3 and 4
+)
+
+// satellite is the decompression state of a single satellite
+type satellite struct {
+	fields []field
+	flags  string
+}
+
+// Reader decompresses a Compact RINEX stream into RINEX text
+type Reader struct {
+	r       *bufio.Reader
+	line    int
+	version int
+	layout  epochLayout
+	buf     bytes.Buffer
+	err     error
+
+	inHeader   bool
+	numTypes   map[byte]int // Number of observation types per system
+	lastSystem byte
+
+	epoch      string
+	clock      field
+	satellites map[string]*satellite
+}
+
+// NewReader returns a Reader which decompresses the CRINEX data in r, having
+// read and validated the two CRINEX header lines.
+func NewReader(r io.Reader) (*Reader, error) {
+	reader := &Reader{
+		r:          bufio.NewReader(r),
+		inHeader:   true,
+		numTypes:   map[byte]int{},
+		clock:      newField(),
+		satellites: map[string]*satellite{},
+	}
+
+	line, err := reader.readLine()
+	if err != nil {
+		return nil, fmt.Errorf("hatanaka: failed to read CRINEX header: %v", err)
+	}
+	if !strings.HasPrefix(scanner.PadRight(line, 80)[60:], VersionLabel) {
+		return nil, fmt.Errorf("hatanaka: first line must be \"%s\"", VersionLabel)
+	}
+	switch strings.TrimSpace(line[:20]) {
+	case "1.0":
+		reader.version, reader.layout = 1, crinex1Layout
+	case "3.0":
+		reader.version, reader.layout = 3, crinex3Layout
+	default:
+		return nil, fmt.Errorf("hatanaka: unsupported CRINEX version \"%s\"", strings.TrimSpace(line[:20]))
+	}
+
+	if line, err = reader.readLine(); err != nil {
+		return nil, fmt.Errorf("hatanaka: failed to read CRINEX header: %v", err)
+	}
+	if !strings.HasPrefix(scanner.PadRight(line, 80)[60:], ProgramLabel) {
+		return nil, fmt.Errorf("hatanaka: second line must be \"%s\"", ProgramLabel)
+	}
+
+	return reader, nil
+}
+
+// Version returns the CRINEX format version, being 1 or 3
+func (r *Reader) Version() int {
+	return r.version
+}
+
+func (r *Reader) Read(p []byte) (int, error) {
+	for r.buf.Len() == 0 && r.err == nil {
+		if r.inHeader {
+			r.err = r.decodeHeaderLine()
+		} else {
+			r.err = r.decodeEpoch()
+		}
+	}
+	if r.buf.Len() > 0 {
+		return r.buf.Read(p)
+	}
+	return 0, r.err
+}
+
+func (r *Reader) readLine() (string, error) {
+	line, err := r.r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return line, err
+	}
+	r.line++
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (r *Reader) writeLine(line string) {
+	r.buf.WriteString(strings.TrimRight(line, " "))
+	r.buf.WriteByte('\n')
+}
+
+func (r *Reader) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("hatanaka: %s at line %d", fmt.Sprintf(format, args...), r.line)
+}
+
+// decodeHeaderLine copies a RINEX header line, keeping track of the number of
+// observation types for each satellite system
+func (r *Reader) decodeHeaderLine() error {
+	line, err := r.readLine()
+	if err == io.EOF {
+		return r.errorf("unexpected end of header")
+	}
+	if err != nil {
+		return err
+	}
+	r.buf.WriteString(line)
+	r.buf.WriteByte('\n')
+
+	padded := scanner.PadRight(line, 80)
+	switch strings.TrimSpace(padded[60:]) {
+	case "SYS / # / OBS TYPES":
+		if padded[0] != ' ' {
+			r.lastSystem = padded[0]
+			if r.numTypes[r.lastSystem], err = strconv.Atoi(strings.TrimSpace(padded[3:6])); err != nil {
+				return r.errorf("invalid number of observation types")
+			}
+		}
+	case "# / TYPES OF OBSERV":
+		if count := strings.TrimSpace(padded[:6]); count != "" {
+			if r.numTypes[0], err = strconv.Atoi(count); err != nil {
+				return r.errorf("invalid number of observation types")
+			}
+		}
+	case "END OF HEADER":
+		r.inHeader = false
+	}
+	return nil
+}
+
+// decodeEpoch decompresses the epoch line, receiver clock offset line and
+// satellite data lines of the next epoch
+func (r *Reader) decodeEpoch() error {
+	line, err := r.readLine()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case r.version == 3 && strings.HasPrefix(line, ">"):
+		r.epoch = line
+	case r.version == 1 && strings.HasPrefix(line, "&"):
+		r.epoch = " " + line[1:]
+	case r.epoch == "":
+		return r.errorf("epoch line without initialisation")
+	default:
+		r.epoch = applyDiff(r.epoch, line)
+	}
+
+	layout := r.layout
+	epoch := scanner.PadRight(r.epoch, layout.satellites)
+	numSats, err := strconv.Atoi(strings.TrimSpace(epoch[layout.numSatellites : layout.numSatellites+3]))
+	if err != nil {
+		return r.errorf("invalid number of satellites")
+	}
+
+	// Event flags 2-5 are followed by special records which are not
+	// compressed, and have no receiver clock offset line
+	if flag := epoch[layout.flag]; flag >= '2' && flag <= '5' {
+		r.writeLine(r.epoch)
+		for i := 0; i < numSats; i++ {
+			if line, err = r.readLine(); err != nil {
+				return r.errorf("missing special record")
+			}
+			r.buf.WriteString(line)
+			r.buf.WriteByte('\n')
+		}
+		return nil
+	}
+
+	clock := ""
+	if line, err = r.readLine(); err != nil {
+		return r.errorf("missing receiver clock offset line")
+	}
+	if line = strings.TrimSpace(line); line == "" {
+		r.clock = newField()
+	} else {
+		value, err := r.clock.decode(line)
+		if err != nil {
+			return r.errorf("invalid receiver clock offset: %v", err)
+		}
+		clock = formatFixed(value, layout.clockDecimals, layout.clockWidth)
+	}
+
+	if len(epoch) < layout.satellites+3*numSats {
+		return r.errorf("missing satellites in epoch line")
+	}
+
+	var records []string
+	satellites := make(map[string]*satellite, numSats)
+	for i := 0; i < numSats; i++ {
+		id := epoch[layout.satellites+3*i : layout.satellites+3*(i+1)]
+		numTypes := r.numTypes[0]
+		if r.version == 3 {
+			numTypes = r.numTypes[id[0]]
+		}
+
+		sat, ok := r.satellites[id]
+		if !ok || len(sat.fields) != numTypes {
+			sat = &satellite{fields: make([]field, numTypes)}
+			for j := range sat.fields {
+				sat.fields[j] = newField()
+			}
+		}
+		satellites[id] = sat
+
+		if line, err = r.readLine(); err != nil {
+			return r.errorf("missing data line for %s", id)
+		}
+		record, err := sat.decode(line)
+		if err != nil {
+			return r.errorf("invalid data for %s: %v", id, err)
+		}
+		records = append(records, record)
+	}
+	r.satellites = satellites
+
+	ids := epoch[layout.satellites : layout.satellites+3*numSats]
+	if r.version == 3 {
+		r.writeLine(epoch[:layout.satellites] + clock)
+		for i, record := range records {
+			r.writeLine(ids[3*i:3*(i+1)] + record)
+		}
+		return nil
+	}
+
+	// RINEX 2 has 12 satellites per epoch line and 5 observations per line
+	for i := 0; i < numSats || i == 0; i += 12 {
+		end := i + 12
+		if end > numSats {
+			end = numSats
+		}
+		if i == 0 {
+			first := epoch[:layout.satellites] + ids[:3*end]
+			if clock != "" {
+				first = scanner.PadRight(first, layout.clock) + clock
+			}
+			r.writeLine(first)
+		} else {
+			r.writeLine(strings.Repeat(" ", layout.satellites) + ids[3*i:3*end])
+		}
+	}
+	for _, record := range records {
+		for i := 0; i < len(record) || i == 0; i += 80 {
+			end := i + 80
+			if end > len(record) {
+				end = len(record)
+			}
+			r.writeLine(record[i:end])
+		}
+	}
+	return nil
+}
+
+// decode decompresses a data line, consisting of a space separated field for
+// each observation type (empty when missing) followed by the flags difference,
+// returning the RINEX observations as F14.3 values each with LLI and SSI
+func (s *satellite) decode(line string) (string, error) {
+	values := make([]string, len(s.fields))
+	p := 0
+	for j := range s.fields {
+		if p >= len(line) || line[p] == ' ' {
+			s.fields[j] = newField()
+			values[j] = strings.Repeat(" ", 14)
+			p++
+			continue
+		}
+
+		end := strings.IndexByte(line[p:], ' ')
+		if end < 0 {
+			end = len(line)
+		} else {
+			end += p
+		}
+		value, err := s.fields[j].decode(line[p:end])
+		if err != nil {
+			return "", err
+		}
+		values[j] = formatFixed(value, 3, 14)
+		p = end + 1
+	}
+
+	if p < len(line) {
+		s.flags = applyDiff(s.flags, line[p:])
+	}
+	flags := scanner.PadRight(s.flags, 2*len(s.fields))
+
+	var record strings.Builder
+	for j, value := range values {
+		record.WriteString(value)
+		record.WriteString(flags[2*j : 2*j+2])
+	}
+	return record.String(), nil
+}
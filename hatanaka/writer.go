@@ -0,0 +1,347 @@
+package hatanaka
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex/scanner"
+)
+
+const DefaultArcOrder = 3
+
+// Writer compresses RINEX observation text written to it into Compact RINEX,
+// using CRINEX 1.0 for RINEX 2 and CRINEX 3.0 for RINEX 3 and 4. The output
+// is decompressed by Reader (and crx2rnx) to the same text, excluding any
+// trailing spaces on each line.
+//
+// Epochs can be written as text produced by any RINEX observation writer,
+// such as the rinex3 package's ObservationWriter.
+type Writer struct {
+	// ArcOrder is the maximum order of differences taken of observations and
+	// receiver clock offsets, which must be set before the first epoch
+	ArcOrder int
+	// Program and Date are written to the CRINEX PROG / DATE line
+	Program string
+	Date    time.Time
+
+	w       io.Writer
+	partial []byte
+	line    int
+	version int
+	layout  epochLayout
+
+	inHeader bool
+	numTypes map[byte]int
+
+	pending    []string // Lines of the epoch being read
+	remaining  int      // Number of lines needed to complete the pending epoch
+	epoch      string
+	clock      field
+	satellites map[string]*satellite
+}
+
+// NewWriter returns a Writer which writes Compact RINEX to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		ArcOrder:   DefaultArcOrder,
+		Program:    "go-gnss/rinex",
+		Date:       time.Now().UTC(),
+		w:          w,
+		inHeader:   true,
+		numTypes:   map[byte]int{},
+		clock:      newField(),
+		satellites: map[string]*satellite{},
+	}
+}
+
+// Compress reads RINEX observation data from src and writes it to dst as
+// Compact RINEX.
+func Compress(dst io.Writer, src io.Reader) error {
+	writer := NewWriter(dst)
+	if _, err := io.Copy(writer, src); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// Write compresses each complete line in p, buffering any partial line until
+// the next call to Write or Close.
+func (w *Writer) Write(p []byte) (int, error) {
+	data := append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(strings.TrimRight(string(data[:i]), "\r ")); err != nil {
+			return 0, err
+		}
+		data = data[i+1:]
+	}
+	w.partial = append([]byte{}, data...)
+	return len(p), nil
+}
+
+// Close compresses any final unterminated line, returning an error if the
+// data ended part way through the header or an epoch. It does not close the
+// underlying io.Writer.
+func (w *Writer) Close() error {
+	if len(w.partial) > 0 {
+		if err := w.writeLine(strings.TrimRight(string(w.partial), "\r ")); err != nil {
+			return err
+		}
+		w.partial = nil
+	}
+	if w.inHeader {
+		return errors.New("hatanaka: incomplete RINEX header")
+	}
+	if len(w.pending) > 0 {
+		return errors.New("hatanaka: incomplete epoch at end of data")
+	}
+	return nil
+}
+
+func (w *Writer) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("hatanaka: %s at line %d", fmt.Sprintf(format, args...), w.line)
+}
+
+func (w *Writer) output(lines ...string) error {
+	for _, line := range lines {
+		if _, err := io.WriteString(w.w, strings.TrimRight(line, " ")+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) writeLine(line string) error {
+	w.line++
+	if w.inHeader {
+		return w.writeHeaderLine(line)
+	}
+
+	if len(w.pending) == 0 {
+		if strings.TrimSpace(line) == "" {
+			return nil // Ignore blank lines between epochs
+		}
+		if err := w.startEpoch(line); err != nil {
+			return err
+		}
+	} else {
+		w.pending = append(w.pending, line)
+		w.remaining--
+	}
+
+	if w.remaining > 0 {
+		return nil
+	}
+	lines := w.pending
+	w.pending = nil
+	return w.writeEpoch(lines)
+}
+
+func (w *Writer) writeHeaderLine(line string) error {
+	padded := scanner.PadRight(line, 80)
+	label := strings.TrimSpace(padded[60:])
+
+	if w.line == 1 {
+		if label != "RINEX VERSION / TYPE" {
+			return w.errorf("first line of header must be \"RINEX VERSION / TYPE\"")
+		}
+		version, err := strconv.ParseFloat(strings.TrimSpace(padded[:9]), 64)
+		if err != nil {
+			return w.errorf("invalid RINEX version")
+		}
+		if padded[20] != 'O' {
+			return w.errorf("only observation files can be compressed")
+		}
+
+		crinex := "3.0"
+		w.version, w.layout = 3, crinex3Layout
+		if version < 3 {
+			crinex = "1.0"
+			w.version, w.layout = 1, crinex1Layout
+		}
+		err = w.output(
+			fmt.Sprintf("%-20s%-40s%s", crinex, "COMPACT RINEX FORMAT", VersionLabel),
+			fmt.Sprintf("%-40.40s%-20.20s%s", w.Program, w.Date.Format("02-Jan-06 15:04"), ProgramLabel),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch label {
+	case "SYS / # / OBS TYPES":
+		if padded[0] != ' ' {
+			count, err := strconv.Atoi(strings.TrimSpace(padded[3:6]))
+			if err != nil {
+				return w.errorf("invalid number of observation types")
+			}
+			w.numTypes[padded[0]] = count
+		}
+	case "# / TYPES OF OBSERV":
+		if count := strings.TrimSpace(padded[:6]); count != "" {
+			numTypes, err := strconv.Atoi(count)
+			if err != nil {
+				return w.errorf("invalid number of observation types")
+			}
+			w.numTypes[0] = numTypes
+		}
+	case "END OF HEADER":
+		w.inHeader = false
+	}
+
+	_, err := io.WriteString(w.w, line+"\n")
+	return err
+}
+
+// startEpoch begins reading an epoch from its epoch line, working out how
+// many more lines belong to the epoch
+func (w *Writer) startEpoch(line string) error {
+	if w.version == 3 && !strings.HasPrefix(line, ">") {
+		return w.errorf("invalid epoch line")
+	}
+
+	padded := scanner.PadRight(line, w.layout.satellites)
+	numSats, err := strconv.Atoi(strings.TrimSpace(padded[w.layout.numSatellites : w.layout.numSatellites+3]))
+	if err != nil {
+		return w.errorf("invalid number of satellites")
+	}
+
+	w.pending = []string{line}
+	w.remaining = numSats
+	if flag := padded[w.layout.flag]; w.version == 1 && (flag < '2' || flag > '5') {
+		numTypes := w.numTypes[0]
+		w.remaining = (numSats+11)/12 - 1 + numSats*((numTypes+4)/5)
+		if numSats == 0 {
+			w.remaining = 0
+		}
+	}
+	return nil
+}
+
+// writeEpoch compresses the lines of a complete epoch
+func (w *Writer) writeEpoch(lines []string) error {
+	layout := w.layout
+	epochLine := scanner.PadRight(lines[0], layout.satellites)
+
+	// Special records are copied as is, and the following epoch line is
+	// initialised rather than differenced
+	if flag := epochLine[layout.flag]; flag >= '2' && flag <= '5' {
+		w.epoch = ""
+		first := lines[0]
+		if w.version == 1 {
+			first = "&" + first[1:]
+		}
+		return w.output(append([]string{first}, lines[1:]...)...)
+	}
+
+	numSats, _ := strconv.Atoi(strings.TrimSpace(epochLine[layout.numSatellites : layout.numSatellites+3]))
+	ids := make([]string, numSats)
+	records := make([]string, numSats)
+	clock := strings.TrimSpace(scanner.PadRight(lines[0], layout.clock+layout.clockWidth)[layout.clock:])
+
+	if w.version == 3 {
+		for i, line := range lines[1:] {
+			line = scanner.PadRight(line, 3)
+			ids[i], records[i] = line[:3], line[3:]
+		}
+	} else {
+		satLines := (numSats + 11) / 12
+		for i := range ids {
+			line := scanner.PadRight(lines[i/12], layout.satellites+36)
+			start := layout.satellites + 3*(i%12)
+			ids[i] = line[start : start+3]
+		}
+		obsLines := (w.numTypes[0] + 4) / 5
+		for i := range records {
+			var record strings.Builder
+			for _, line := range lines[satLines+i*obsLines : satLines+(i+1)*obsLines] {
+				record.WriteString(scanner.PadRight(line, 80))
+			}
+			records[i] = record.String()
+		}
+	}
+
+	epoch := epochLine[:layout.satellites] + strings.Join(ids, "")
+	var out []string
+	switch {
+	case w.epoch == "" && w.version == 3:
+		out = append(out, epoch)
+	case w.epoch == "":
+		out = append(out, "&"+epoch[1:])
+	default:
+		out = append(out, textDiff(w.epoch, epoch))
+	}
+	w.epoch = epoch
+
+	if clock == "" {
+		w.clock = newField()
+		out = append(out, "")
+	} else {
+		value, err := parseFixed(clock, w.layout.clockDecimals)
+		if err != nil {
+			return w.errorf("invalid receiver clock offset")
+		}
+		out = append(out, w.clock.encode(value, w.ArcOrder))
+	}
+
+	satellites := make(map[string]*satellite, numSats)
+	for i, id := range ids {
+		numTypes := w.numTypes[0]
+		if w.version == 3 {
+			numTypes = w.numTypes[id[0]]
+		}
+
+		sat, ok := w.satellites[id]
+		if !ok || len(sat.fields) != numTypes {
+			sat = &satellite{fields: make([]field, numTypes)}
+			for j := range sat.fields {
+				sat.fields[j] = newField()
+			}
+		}
+		satellites[id] = sat
+
+		line, err := sat.encode(records[i], w.ArcOrder)
+		if err != nil {
+			return w.errorf("invalid data for %s: %v", id, err)
+		}
+		out = append(out, line)
+	}
+	w.satellites = satellites
+
+	return w.output(out...)
+}
+
+// encode compresses the RINEX observations of a satellite into a data line,
+// being the inverse of decode
+func (s *satellite) encode(record string, arcOrder int) (string, error) {
+	record = scanner.PadRight(record, 16*len(s.fields))
+
+	fields := make([]string, len(s.fields))
+	var flags strings.Builder
+	for j := range s.fields {
+		value := record[16*j : 16*j+14]
+		flags.WriteString(record[16*j+14 : 16*j+16])
+
+		if strings.TrimSpace(value) == "" {
+			s.fields[j] = newField()
+			continue
+		}
+		v, err := parseFixed(value, 3)
+		if err != nil {
+			return "", err
+		}
+		fields[j] = s.fields[j].encode(v, arcOrder)
+	}
+
+	diff := textDiff(s.flags, flags.String())
+	s.flags = flags.String()
+	return strings.TrimRight(strings.Join(fields, " ")+" "+diff, " "), nil
+}
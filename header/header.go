@@ -1,5 +1,11 @@
 package header
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Free ordering of Header section, with Exceptions:
 // RINEX VERSION / TYPE record MUST always be the first record in a file
 // SYS / # / OBS TYPES record(s) should precede any SYS / DCBS APPLIED and SYS / SCALE FACTOR records
@@ -19,6 +25,10 @@ type Header struct {
 	RunBy           string
 	CreationDate    string // TODO: time.Time
 	Comments        []HeaderComment
+	// Records are the header's records in the order they were read from a
+	// file, including comments and unknown labels, which are written by
+	// FormatHeaderRecords to reproduce the header
+	Records []HeaderRecord
 }
 
 func (h Header) GetFormatVersion() float64 {
@@ -29,7 +39,89 @@ func (h Header) GetFileType() string {
 	return h.FileType
 }
 
+// LabelColumn returns the index of the column at which the labels of the
+// header records start, being column 66 of clock files since version 3.04
+// and column 61 of all other files.
+func (h Header) LabelColumn() int {
+	if h.FileType == "C" && h.FormatVersion >= 3.04 {
+		return clockLabelColumn
+	}
+	return labelColumn
+}
+
+func (h Header) GetRecords() []HeaderRecord {
+	return h.Records
+}
+
 type HeaderComment struct {
 	Comment string
-	Line    int // TODO: This might not be useful for reconstructing Headers if additional lines are added
+	Line    int // Line of the file, matching the Line of its record in Records
+}
+
+// ParseMode controls how violations of the header format are handled
+type ParseMode int
+
+const (
+	// Strict stops parsing at the first violation, returning it as a
+	// RecordError
+	Strict ParseMode = iota
+	// Lenient collects violations as warnings and continues parsing, keeping
+	// records with unknown labels
+	Lenient
+)
+
+// RecordError is a violation of the header format at a line of the file,
+// being an invalid record, an unknown label or an ordering rule violation
+type RecordError struct {
+	Line int
+	Key  string
+	Err  error
+}
+
+func (e RecordError) Error() string {
+	return fmt.Sprintf("failed to parse header record at line %d with reason: %s", e.Line, e.Err.Error())
+}
+
+// RecordOrder checks the ordering rules at the top of this file as each
+// record following RINEX VERSION / TYPE is parsed
+type RecordOrder struct {
+	seen       map[string]bool
+	satellites int // PRN / # OF OBS records still expected
+}
+
+// Check returns an error if hr violates an ordering rule, given the records
+// which were checked before it.
+func (o *RecordOrder) Check(hr HeaderRecord) (err error) {
+	if o.seen == nil {
+		o.seen = map[string]bool{}
+	}
+	defer func() { o.seen[hr.Key] = true }()
+
+	if o.satellites > 0 && hr.Key != "PRN / # OF OBS" && hr.Key != "COMMENT" {
+		err = fmt.Errorf("\"# OF SATELLITES\" must be followed by %d more \"PRN / # OF OBS\" records", o.satellites)
+		o.satellites = 0
+		return err
+	}
+
+	switch hr.Key {
+	case "RINEX VERSION / TYPE":
+		return fmt.Errorf("\"RINEX VERSION / TYPE\" must only be the first record")
+	case "SYS / DCBS APPLIED", "SYS / SCALE FACTOR":
+		if !o.seen["SYS / # / OBS TYPES"] {
+			return fmt.Errorf("\"SYS / # / OBS TYPES\" must precede \"%s\"", hr.Key)
+		}
+	case "# OF SATELLITES":
+		if len(hr.Value) >= 6 {
+			o.satellites, _ = strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+		}
+	case "PRN / # OF OBS":
+		// Continuation lines have a blank satellite
+		if o.seen["# OF SATELLITES"] && len(hr.Value) >= 6 && strings.TrimSpace(hr.Value[3:6]) != "" {
+			if o.satellites == 0 {
+				return fmt.Errorf("more \"PRN / # OF OBS\" records than \"# OF SATELLITES\"")
+			}
+			o.satellites--
+		}
+	}
+	return nil
 }
@@ -30,7 +30,7 @@ var (
 			return nil
 		},
 		"COMMENT": func(_ *scanner.Scanner, h *Header, hr HeaderRecord) error {
-			h.Comments = append(h.Comments, HeaderComment{hr.Value[:60], hr.Line})
+			h.Comments = append(h.Comments, HeaderComment{hr.Value, hr.Line})
 			return nil
 		},
 		"END OF HEADER": func(s *scanner.Scanner, h *Header, hr HeaderRecord) error {
@@ -39,24 +39,193 @@ var (
 	}
 )
 
+const (
+	// labelColumn is the index of the column at which header labels start
+	labelColumn = 60
+	// clockLabelColumn is the index of the column at which header labels
+	// start in clock files since version 3.04, which have labels in columns
+	// 66-85
+	clockLabelColumn = 65
+)
+
 type HeaderRecord struct {
 	Value string
 	Key   string
 	Line  int
+	Raw   string // The line as it was read, without its line terminator
 }
 
 func ParseHeaderRecord(scanner *scanner.Scanner) (hr HeaderRecord, err error) {
+	return ParseHeaderRecordAt(scanner, labelColumn)
+}
+
+// ParseHeaderRecordAt parses the next header record of a file whose labels
+// start at the column index given by Header.LabelColumn.
+func ParseHeaderRecordAt(scanner *scanner.Scanner, column int) (hr HeaderRecord, err error) {
 	line, err := scanner.ReadLine()
 	if err != nil {
 		return hr, err
 	}
+	return NewHeaderRecordAt(line, scanner.Line, column)
+}
+
+// NewHeaderRecord parses a header line, returning a HeaderRecord with only
+// the Raw line and its Line number if it isn't a valid record.
+func NewHeaderRecord(raw string, lineNumber int) (HeaderRecord, error) {
+	return NewHeaderRecordAt(raw, lineNumber, labelColumn)
+}
+
+// NewHeaderRecordAt parses a header line whose label starts at a column
+// index, with the label being at most 20 characters.
+func NewHeaderRecordAt(raw string, lineNumber int, column int) (HeaderRecord, error) {
+	line := strings.TrimRight(raw, " \n")
+	if len(line) <= column || len(line) > column+20 {
+		return HeaderRecord{Line: lineNumber, Raw: raw}, errors.New(fmt.Sprintf("invalid header line \"%s\"", line))
+	}
+
+	return HeaderRecord{line[:column], line[column:], lineNumber, raw}, nil
+}
 
+// DetectLabelColumn returns the index of the column at which the label of
+// the RINEX VERSION / TYPE line of a file starts, which is later in clock
+// files since version 3.04 than in other files. The column is checked
+// against the version and type by Header.LabelColumn once it's parsed.
+func DetectLabelColumn(line string) int {
 	line = strings.TrimRight(line, " \n")
-	if len(line) < 61 || len(line) > 80 {
-		return hr, errors.New(fmt.Sprintf("invalid header line \"%s\"", line))
+	if len(line) > clockLabelColumn && line[clockLabelColumn:] == "RINEX VERSION / TYPE" {
+		return clockLabelColumn
+	}
+	return labelColumn
+}
+
+// String returns the Raw line of a record which was read from a file, or
+// otherwise formats its Value and Key, without a line terminator.
+func (hr HeaderRecord) String() string {
+	if hr.Raw != "" || (hr.Value == "" && hr.Key == "") {
+		return hr.Raw
+	}
+	return strings.TrimSuffix(FormatHeaderRecord(hr.Value, hr.Key), "\n")
+}
+
+// NewHeaderRecords parses the raw lines of a header, starting from the
+// RINEX VERSION / TYPE record at line 1, keeping invalid lines as records
+// with only a Raw line.
+func NewHeaderRecords(lines []string) []HeaderRecord {
+	records := make([]HeaderRecord, len(lines))
+	column := labelColumn
+	if len(lines) > 0 {
+		column = DetectLabelColumn(lines[0])
+	}
+	for i, line := range lines {
+		records[i], _ = NewHeaderRecordAt(line, i+1, column)
+	}
+	return records
+}
+
+// FormatHeaderRecords formats records as the lines of a header, so that a
+// header which was read from a file is written byte-for-byte, apart from
+// line terminators being written as "\n".
+func FormatHeaderRecords(records []HeaderRecord) string {
+	var b strings.Builder
+	for _, hr := range records {
+		b.WriteString(hr.String() + "\n")
 	}
+	return b.String()
+}
+
+// ReplaceHeaderRecords returns a copy of records with those having the given
+// key replaced by lines formatted with FormatHeaderRecord, in the position of
+// the first replaced record. If there are no records with the key, the lines
+// are inserted before END OF HEADER. All other records are kept as they are,
+// and replacing records with no lines removes them.
+func ReplaceHeaderRecords(records []HeaderRecord, key string, lines []string) []HeaderRecord {
+	replacements := make([]HeaderRecord, 0, len(lines))
+	for _, line := range lines {
+		hr, _ := NewHeaderRecord(strings.TrimSuffix(line, "\n"), 0)
+		replacements = append(replacements, hr)
+	}
+
+	position := -1
+	replaced := make([]HeaderRecord, 0, len(records)+len(lines))
+	for _, hr := range records {
+		if hr.Key == key {
+			if position == -1 {
+				position = len(replaced)
+			}
+			continue
+		}
+		replaced = append(replaced, hr)
+	}
+	if position == -1 {
+		position = len(replaced)
+		for i, hr := range replaced {
+			if hr.Key == "END OF HEADER" {
+				position = i
+				break
+			}
+		}
+	}
+
+	return append(replaced[:position], append(replacements, replaced[position:]...)...)
+}
+
+// UpdateHeaderRecords returns a copy of records updated with other records,
+// such as those of a special event in the data section, which replace the
+// records with the same key as with ReplaceHeaderRecords. Comments, and
+// records with any of the appendedKeys, are instead added after the last
+// record with the same key, or before END OF HEADER if there is none.
+func UpdateHeaderRecords(records, updates []HeaderRecord, appendedKeys ...string) []HeaderRecord {
+	appended := map[string]bool{"COMMENT": true}
+	for _, key := range appendedKeys {
+		appended[key] = true
+	}
+
+	updated := append([]HeaderRecord{}, records...)
+	var keys []string
+	lines := map[string][]string{}
+	for _, hr := range updates {
+		if appended[hr.Key] {
+			updated = appendHeaderRecord(updated, hr)
+			continue
+		}
+		if _, ok := lines[hr.Key]; !ok {
+			keys = append(keys, hr.Key)
+		}
+		lines[hr.Key] = append(lines[hr.Key], hr.String())
+	}
+
+	for _, key := range keys {
+		updated = ReplaceHeaderRecords(updated, key, lines[key])
+	}
+	return updated
+}
+
+// appendHeaderRecord inserts a new record after the last record with the same
+// key, or before END OF HEADER
+func appendHeaderRecord(records []HeaderRecord, hr HeaderRecord) []HeaderRecord {
+	position := -1
+	for i, existing := range records {
+		if existing.Key == hr.Key {
+			position = i + 1
+		} else if existing.Key == "END OF HEADER" && position == -1 {
+			position = i
+		}
+	}
+	if position == -1 {
+		position = len(records)
+	}
+
+	record, _ := NewHeaderRecord(strings.TrimSuffix(hr.String(), "\n"), 0)
+	records = append(records, HeaderRecord{})
+	copy(records[position+1:], records[position:])
+	records[position] = record
+	return records
+}
 
-	return HeaderRecord{line[:60], line[60:], scanner.Line}, err
+// FormatHeaderRecord formats a header line from the value in columns 1-60,
+// which is padded or truncated to fit, and the header label in columns 61-80.
+func FormatHeaderRecord(value, key string) string {
+	return fmt.Sprintf("%-60.60s%-.20s\n", value, key)
 }
 
 type HeaderRecordParsingError error
@@ -0,0 +1,123 @@
+package rinex2
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/scanner"
+)
+
+type ObservationHeader struct {
+	header.Header
+	Marker struct {
+		Name           string
+		Number         string
+		ApproxPosition struct {
+			X float64
+			Y float64
+			Z float64
+		}
+	}
+	Observer string
+	Agency   string
+	Receiver struct {
+		Number  string
+		Type    string
+		Version string
+	}
+	Antenna struct {
+		Number string
+		Type   string
+		Height float64
+		East   float64
+		North  float64
+	}
+	WavelengthFactors WavelengthFactors
+	// RINEX 2 uses a single list of observation types for every satellite
+	// system, with two character codes such as C1, P2 and L1
+	ObservationTypes    []gnss.ObservationCode
+	Interval            float64
+	TimeOfFirstObs      gnss.Time
+	TimeOfLastObs       gnss.Time
+	ClockOffsetsApplied bool
+	LeapSeconds         int
+}
+
+// WavelengthFactors holds the default L1 and L2 wavelength factors along with
+// any satellite specific overrides, where 1 indicates full cycle ambiguities
+// and 2 indicates half cycle ambiguities (squaring type receivers)
+type WavelengthFactors struct {
+	L1         int
+	L2         int
+	Satellites map[gnss.SatelliteID][2]int
+}
+
+func NewObservationHeader(header header.Header) ObservationHeader {
+	obsHeader := ObservationHeader{Header: header}
+	obsHeader.WavelengthFactors.Satellites = map[gnss.SatelliteID][2]int{}
+	return obsHeader
+}
+
+// WithRecords returns a copy of the header with header records applied, such
+// as those of a special event in the data section, which replace the values
+// of the records with the same labels. Records with unknown labels are
+// skipped, apart from being added to Records along with the others, as
+// with header.UpdateHeaderRecords.
+func (h ObservationHeader) WithRecords(records []header.HeaderRecord) (ObservationHeader, error) {
+	h.Comments = h.Comments[:len(h.Comments):len(h.Comments)]
+	satellites := map[gnss.SatelliteID][2]int{}
+	for satellite, factors := range h.WavelengthFactors.Satellites {
+		satellites[satellite] = factors
+	}
+	h.WavelengthFactors.Satellites = satellites
+	h.Records = header.UpdateHeaderRecords(h.Records, records, "WAVELENGTH FACT L1/2")
+
+	s := &scanner.Scanner{Reader: bufio.NewReader(strings.NewReader(header.FormatHeaderRecords(records)))}
+	for {
+		hr, err := header.ParseHeaderRecord(s)
+		if err == io.EOF {
+			return h, nil
+		}
+		if err != nil {
+			return h, err
+		}
+
+		if parser, ok := header.HeaderRecordParsers[hr.Key]; ok {
+			err = parser(s, &h.Header, hr)
+		} else if parser, ok := ObservationHeaderRecordParsers[hr.Key]; ok {
+			err = parser(s, &h, hr)
+		}
+		if err != nil {
+			return h, header.NewHeaderRecordParsingError(err, records[0].Line+s.Line-1)
+		}
+	}
+}
+
+// TimeSystem returns the time system of the epochs of the file, being that of
+// TIME OF FIRST OBS, which defaults to the time system of the satellite
+// system of a single system file, or GPS time if it's missing from a mixed
+// file.
+func (h ObservationHeader) TimeSystem() gnss.TimeSystem {
+	if system := h.TimeOfFirstObs.System(); system != "" {
+		return system
+	}
+	if system := gnss.SatelliteSystem(h.SatelliteSystem).TimeSystem(); system != "" {
+		return system
+	}
+	return gnss.GPSTime
+}
+
+func ParseObservationHeader(scanner *scanner.Scanner, header *ObservationHeader) error {
+	for {
+		hr, err := ParseObservationHeaderRecord(scanner, header)
+		if err != nil {
+			return err
+		}
+		if hr.Key == "END OF HEADER" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package rinex2
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/rinex3"
+	"github.com/go-gnss/rinex/scanner"
+)
+
+type ObservationHeaderRecordParser func(*scanner.Scanner, *ObservationHeader, header.HeaderRecord) error
+
+var (
+	ObservationHeaderRecordParsers map[string]ObservationHeaderRecordParser = map[string]ObservationHeaderRecordParser{
+		"MARKER NAME": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) error {
+			h.Marker.Name = strings.TrimSpace(hr.Value)
+			return nil
+		},
+		"MARKER NUMBER": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) error {
+			h.Marker.Number = strings.TrimSpace(hr.Value[:20])
+			return nil
+		},
+		"OBSERVER / AGENCY": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) error {
+			h.Observer = strings.TrimSpace(hr.Value[:20])
+			h.Agency = strings.TrimSpace(hr.Value[20:])
+			return nil
+		},
+		"REC # / TYPE / VERS": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) error {
+			h.Receiver.Number = strings.TrimSpace(hr.Value[:20])
+			h.Receiver.Type = strings.TrimSpace(hr.Value[20:40])
+			h.Receiver.Version = strings.TrimSpace(hr.Value[40:])
+			return nil
+		},
+		"ANT # / TYPE": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) error {
+			h.Antenna.Number = strings.TrimSpace(hr.Value[:20])
+			h.Antenna.Type = strings.TrimSpace(hr.Value[20:40])
+			return nil
+		},
+		"APPROX POSITION XYZ": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			h.Marker.ApproxPosition.X, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[:14]), 64)
+			if err != nil {
+				return err
+			}
+			h.Marker.ApproxPosition.Y, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[14:28]), 64)
+			if err != nil {
+				return err
+			}
+			h.Marker.ApproxPosition.Z, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[28:42]), 64)
+			return err
+		},
+		"ANTENNA: DELTA H/E/N": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			h.Antenna.Height, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[:14]), 64)
+			if err != nil {
+				return err
+			}
+			h.Antenna.East, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[14:28]), 64)
+			if err != nil {
+				return err
+			}
+			h.Antenna.North, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[28:42]), 64)
+			return err
+		},
+		"WAVELENGTH FACT L1/2": func(s *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			l1, err := strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+			if err != nil {
+				return err
+			}
+			l2 := 0 // Blank or zero for single frequency receivers
+			if field := strings.TrimSpace(hr.Value[6:12]); field != "" {
+				if l2, err = strconv.Atoi(field); err != nil {
+					return err
+				}
+			}
+
+			numSats := 0
+			if field := strings.TrimSpace(hr.Value[12:18]); field != "" {
+				if numSats, err = strconv.Atoi(field); err != nil {
+					return err
+				}
+			}
+			if numSats == 0 {
+				h.WavelengthFactors.L1, h.WavelengthFactors.L2 = l1, l2
+				return nil
+			}
+
+			// Satellite specific factors, formatted as 7(3X,A1,I2), with
+			// continuation lines for more than 7 satellites
+			for i := 0; i < numSats; i++ {
+				if i > 0 && i%7 == 0 {
+					hr, err = header.ParseHeaderRecord(s)
+					if err != nil {
+						return err
+					}
+					if hr.Key != "WAVELENGTH FACT L1/2" {
+						return rinex3.HeaderRecordPatternError
+					}
+				}
+				column := 18 + 6*(i%7)
+				sat, err := satelliteID(hr.Value[column+3 : column+6])
+				if err != nil {
+					return err
+				}
+				h.WavelengthFactors.Satellites[sat] = [2]int{l1, l2}
+			}
+			return nil
+		},
+		"# / TYPES OF OBSERV": func(s *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			totalObs, err := strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+			if err != nil {
+				return err
+			}
+
+			h.ObservationTypes = nil
+			for { // Handle continuation lines, each containing up to 9 types
+				for i := 0; i < 9 && len(h.ObservationTypes) < totalObs; i++ {
+					obsType := strings.TrimSpace(hr.Value[6+(6*i) : 12+(6*i)])
+					if obsType == "" {
+						return rinex3.HeaderRecordPatternError
+					}
+					code, err := gnss.ParseObservationCode(obsType)
+					if err != nil {
+						return err
+					}
+					h.ObservationTypes = append(h.ObservationTypes, code)
+				}
+				if len(h.ObservationTypes) == totalObs {
+					return nil
+				}
+
+				hr, err = header.ParseHeaderRecord(s)
+				if err != nil {
+					return err
+				}
+				if hr.Key != "# / TYPES OF OBSERV" {
+					return rinex3.HeaderRecordPatternError
+				}
+			}
+		},
+		"INTERVAL": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			h.Interval, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[:10]), 64)
+			return err
+		},
+		"TIME OF FIRST OBS": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			h.TimeOfFirstObs, err = rinex3.ParseTimeRecord(hr.Value)
+			return err
+		},
+		"TIME OF LAST OBS": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			h.TimeOfLastObs, err = rinex3.ParseTimeRecord(hr.Value)
+			return err
+		},
+		"RCV CLOCK OFFS APPL": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			applied, err := strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+			h.ClockOffsetsApplied = applied == 1
+			return err
+		},
+		"LEAP SECONDS": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			h.LeapSeconds, err = strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+			return err
+		},
+	}
+)
+
+// ParseObservationHeaderRecord parses the next header record into obsHeader.
+// Records which aren't defined for RINEX 2 observation files (such as
+// # OF SATELLITES, PRN / # OF OBS or receiver specific labels) are skipped.
+func ParseObservationHeaderRecord(scanner *scanner.Scanner, obsHeader *ObservationHeader) (hr header.HeaderRecord, err error) {
+	hr, err = header.ParseHeaderRecord(scanner)
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+
+	if parser, ok := header.HeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, &obsHeader.Header, hr)
+	} else if parser, ok := ObservationHeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, obsHeader, hr)
+	}
+
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+	return hr, nil
+}
+
+// satelliteID parses a RINEX 2 satellite identifier, for which a blank
+// system identifier means GPS
+func satelliteID(id string) (gnss.SatelliteID, error) {
+	if id[0] == ' ' {
+		id = "G" + id[1:]
+	}
+	return gnss.ParseSatelliteID(id)
+}
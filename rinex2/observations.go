@@ -0,0 +1,146 @@
+package rinex2
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/rinex3"
+	"github.com/go-gnss/rinex/scanner"
+)
+
+const (
+	satellitesPerLine   = 12
+	observationsPerLine = 5
+)
+
+// ParseEpochRecord parses a RINEX 2 epoch, including any satellite list and
+// observation continuation lines, into the same EpochRecord type used for
+// RINEX 3 so that callers don't need to care which version a file is. The
+// epoch is in the time system of the file.
+func ParseEpochRecord(s *scanner.Scanner, observationTypes []gnss.ObservationCode, system gnss.TimeSystem) (epoch rinex3.EpochRecord, err error) {
+	line, err := s.ReadLine()
+	if err != nil {
+		return epoch, err
+	}
+
+	if len(line) < 32 {
+		return epoch, fmt.Errorf("invalid epoch record at line %d", s.Line)
+	}
+
+	flag, err := strconv.ParseInt(line[28:29], 10, 8)
+	if err != nil {
+		return epoch, err
+	}
+	epoch.Flag = rinex3.EpochFlag(flag)
+
+	// The time of special events may be left blank
+	if strings.TrimSpace(line[:26]) != "" || !epoch.Flag.IsEvent() {
+		epoch.Time, err = parseEpochTime(line[:26], system)
+		if err != nil {
+			return epoch, fmt.Errorf("invalid epoch time at line %d: %v", s.Line, err)
+		}
+	}
+
+	numSats, err := strconv.Atoi(strings.TrimSpace(line[29:32]))
+	if err != nil {
+		return epoch, err
+	}
+	epoch.NumSatellites = numSats
+
+	if len(line) > 68 {
+		if offset := strings.TrimSpace(line[68:]); offset != "" {
+			epoch.ClockOffset, err = strconv.ParseFloat(offset, 64)
+			if err != nil {
+				return epoch, err
+			}
+		}
+	}
+
+	// Event flags 2-5 are followed by numSats header records rather than
+	// observations
+	if epoch.Flag.IsEvent() {
+		epoch.HeaderRecords, err = rinex3.ParseEventRecords(s, numSats)
+		return epoch, err
+	}
+
+	// Satellite list, continued on following lines for more than 12 satellites
+	satellites := make([]gnss.SatelliteID, 0, numSats)
+	for i := 0; i < numSats; i++ {
+		if i > 0 && i%satellitesPerLine == 0 {
+			if line, err = s.ReadLine(); err != nil {
+				return epoch, err
+			}
+		}
+		start := 32 + 3*(i%satellitesPerLine)
+		if len(line) < start+3 {
+			return epoch, fmt.Errorf("missing satellites in epoch record at line %d", s.Line)
+		}
+		sat, err := satelliteID(line[start : start+3])
+		if err != nil {
+			return epoch, fmt.Errorf("invalid satellite in epoch record at line %d: %v", s.Line, err)
+		}
+		satellites = append(satellites, sat)
+	}
+
+	for _, sat := range satellites {
+		record, err := parseObservationRecord(s, sat, observationTypes)
+		if err != nil {
+			return epoch, err
+		}
+		epoch.ObservationRecords = append(epoch.ObservationRecords, record)
+	}
+
+	return epoch, nil
+}
+
+// parseEpochTime parses the " yy mm dd hh mm ss.sssssss" epoch time fields,
+// where two digit years 80-99 refer to 1980-1999
+func parseEpochTime(fields string, system gnss.TimeSystem) (t gnss.Time, err error) {
+	var values [5]int
+	for i := range values {
+		if values[i], err = strconv.Atoi(strings.TrimSpace(fields[3*i : 3*i+3])); err != nil {
+			return t, err
+		}
+	}
+
+	year := values[0] + 2000
+	if values[0] >= 80 {
+		year = values[0] + 1900
+	}
+
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(fields[15:26]), 64)
+	if err != nil {
+		return t, err
+	}
+
+	return gnss.Date(system, year, time.Month(values[1]), values[2], values[3], values[4], seconds), nil
+}
+
+// parseObservationRecord reads the observations for a single satellite, which
+// are wrapped onto a new line after every five observations
+func parseObservationRecord(s *scanner.Scanner, sat gnss.SatelliteID, observationTypes []gnss.ObservationCode) (record rinex3.ObservationRecord, err error) {
+	record.Satellite = sat
+	numTypes := len(observationTypes)
+
+	for i := 0; i < numTypes; i += observationsPerLine {
+		line, err := s.ReadLine()
+		if err != nil {
+			return record, err
+		}
+		line += strings.Repeat(" ", 80) // Trailing blank fields are often trimmed
+
+		for j := 0; j < observationsPerLine && i+j < numTypes; j++ {
+			observation, err := rinex3.ParseObservation(line[16*j : 16*(j+1)])
+			if err != nil {
+				return record, fmt.Errorf("invalid observation at line %d: %v", s.Line, err)
+			}
+			observation.Code = observationTypes[i+j]
+			record.Observations = append(record.Observations, observation)
+		}
+	}
+
+	return record, nil
+}
@@ -0,0 +1,131 @@
+package rinex3
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex/scanner"
+)
+
+// ClockRecord is a single clock data line, with values in seconds (and
+// seconds per second for Rate, seconds per second squared for Acceleration).
+// Only the first NumValues values are present in the file.
+type ClockRecord struct {
+	Type              string // AR, AS, CR, DR or MS
+	Name              string // Receiver/station name or satellite ID
+	Time              time.Time
+	NumValues         int
+	Bias              float64
+	BiasSigma         float64
+	Rate              float64
+	RateSigma         float64
+	Acceleration      float64
+	AccelerationSigma float64
+}
+
+// ParseClockRecord parses the next clock data record, being the first line
+// with up to two values and a continuation line if there are more than two.
+// Fields are separated by spaces, so the same parser is used for the 4
+// character names of version 3.00 and the 9 character names of 3.04.
+func ParseClockRecord(s *scanner.Scanner) (record ClockRecord, err error) {
+	line, err := s.ReadLine()
+	for err == nil && strings.TrimSpace(line) == "" {
+		line, err = s.ReadLine()
+	}
+	if err != nil {
+		return record, err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 10 {
+		return record, fmt.Errorf("invalid clock record at line %d", s.Line)
+	}
+	record.Type, record.Name = fields[0], fields[1]
+
+	var date [5]int
+	for i := range date {
+		if date[i], err = strconv.Atoi(fields[2+i]); err != nil {
+			return record, fmt.Errorf("invalid clock record epoch at line %d: %v", s.Line, err)
+		}
+	}
+	seconds, err := strconv.ParseFloat(fields[7], 64)
+	if err != nil {
+		return record, fmt.Errorf("invalid clock record epoch at line %d: %v", s.Line, err)
+	}
+	record.Time = time.Date(date[0], time.Month(date[1]), date[2], date[3], date[4], 0, 0, time.UTC).
+		Add(time.Duration(math.Round(seconds*1e6)) * time.Microsecond)
+
+	if record.NumValues, err = strconv.Atoi(fields[8]); err != nil || record.NumValues < 1 || record.NumValues > 6 {
+		return record, fmt.Errorf("invalid number of clock values at line %d", s.Line)
+	}
+
+	values := fields[9:]
+	if record.NumValues > 2 {
+		if line, err = s.ReadLine(); err != nil {
+			return record, err
+		}
+		values = append(values, strings.Fields(line)...)
+	}
+	if len(values) < record.NumValues {
+		return record, fmt.Errorf("missing clock values at line %d", s.Line)
+	}
+
+	fieldPointers := []*float64{
+		&record.Bias, &record.BiasSigma, &record.Rate,
+		&record.RateSigma, &record.Acceleration, &record.AccelerationSigma,
+	}
+	for i := 0; i < record.NumValues; i++ {
+		if *fieldPointers[i], err = ParseFortranFloat(values[i]); err != nil {
+			return record, fmt.Errorf("invalid clock value at line %d: %v", s.Line, err)
+		}
+	}
+
+	return record, nil
+}
+
+// SatelliteClocks holds the AS (satellite clock) records of a clock file
+// for each satellite, in time order
+type SatelliteClocks map[string][]ClockRecord
+
+// NewSatelliteClocks collects the AS records from records, ignoring all other
+// record types
+func NewSatelliteClocks(records []ClockRecord) SatelliteClocks {
+	clocks := SatelliteClocks{}
+	for _, record := range records {
+		if record.Type == "AS" {
+			clocks[record.Name] = append(clocks[record.Name], record)
+		}
+	}
+	for _, satRecords := range clocks {
+		sort.SliceStable(satRecords, func(i, j int) bool {
+			return satRecords[i].Time.Before(satRecords[j].Time)
+		})
+	}
+	return clocks
+}
+
+// Bias returns the clock bias of the satellite at t in seconds, linearly
+// interpolating between the two nearest records. An error is returned if t
+// is outside of the span of the satellite's records, as extrapolating clocks
+// quickly becomes inaccurate.
+func (c SatelliteClocks) Bias(satellite string, t time.Time) (float64, error) {
+	records := c[satellite]
+	i := sort.Search(len(records), func(i int) bool {
+		return !records[i].Time.Before(t)
+	})
+
+	if i == len(records) || (i == 0 && !records[0].Time.Equal(t)) {
+		return 0, fmt.Errorf("no clock records for %s around %v", satellite, t)
+	}
+	if records[i].Time.Equal(t) {
+		return records[i].Bias, nil
+	}
+
+	before, after := records[i-1], records[i]
+	fraction := float64(t.Sub(before.Time)) / float64(after.Time.Sub(before.Time))
+	return before.Bias + fraction*(after.Bias-before.Bias), nil
+}
@@ -0,0 +1,140 @@
+package rinex3
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/scanner"
+)
+
+// ClockHeader is the header of a Clock RINEX file, which is also used for
+// version 2 clock files as the header records are the same
+type ClockHeader struct {
+	header.Header
+	TimeSystem  string
+	LeapSeconds int
+	// Clock data types in the file: AR, AS, CR, DR and MS
+	DataTypes      []string
+	AnalysisCenter struct {
+		ID   string
+		Name string
+	}
+	ReferenceFrame string
+	Stations       []ClockStation
+	Satellites     []string
+}
+
+// ClockStation is a SOLN STA NAME / NUM record, with coordinates in metres
+type ClockStation struct {
+	Name       string
+	Identifier string
+	X          float64
+	Y          float64
+	Z          float64
+}
+
+type ClockHeaderRecordParser func(*scanner.Scanner, *ClockHeader, header.HeaderRecord) error
+
+var (
+	ClockHeaderRecordParsers map[string]ClockHeaderRecordParser = map[string]ClockHeaderRecordParser{
+		"TIME SYSTEM ID": func(_ *scanner.Scanner, h *ClockHeader, hr header.HeaderRecord) error {
+			h.TimeSystem = strings.TrimSpace(hr.Value[:6])
+			return nil
+		},
+		"LEAP SECONDS": func(_ *scanner.Scanner, h *ClockHeader, hr header.HeaderRecord) (err error) {
+			h.LeapSeconds, err = strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+			return err
+		},
+		"# / TYPES OF DATA": func(_ *scanner.Scanner, h *ClockHeader, hr header.HeaderRecord) (err error) {
+			numTypes, err := strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+			if err != nil {
+				return err
+			}
+			h.DataTypes = strings.Fields(hr.Value[6:])
+			if len(h.DataTypes) != numTypes {
+				return HeaderRecordPatternError
+			}
+			return nil
+		},
+		"ANALYSIS CENTER": func(_ *scanner.Scanner, h *ClockHeader, hr header.HeaderRecord) error {
+			h.AnalysisCenter.ID = strings.TrimSpace(hr.Value[:3])
+			h.AnalysisCenter.Name = strings.TrimSpace(hr.Value[5:])
+			return nil
+		},
+		"# OF SOLN STA / TRF": func(_ *scanner.Scanner, h *ClockHeader, hr header.HeaderRecord) error {
+			h.ReferenceFrame = strings.TrimSpace(hr.Value[10:])
+			return nil
+		},
+		"SOLN STA NAME / NUM": func(_ *scanner.Scanner, h *ClockHeader, hr header.HeaderRecord) (err error) {
+			// Station names are 4 characters before version 3.04 and 9
+			// characters since, followed by a 20 character identifier and the
+			// coordinates in millimetres in 11 character fields separated by
+			// a space
+			width := 4
+			if h.FormatVersion >= 3.04 {
+				width = 9
+			}
+			if len(hr.Value) < width+56 {
+				return HeaderRecordPatternError
+			}
+
+			station := ClockStation{
+				Name:       strings.TrimSpace(hr.Value[:width]),
+				Identifier: strings.TrimSpace(hr.Value[width+1 : width+21]),
+			}
+			coordinates := []*float64{&station.X, &station.Y, &station.Z}
+			for i := range coordinates {
+				start := width + 21 + 12*i
+				mm, err := strconv.ParseInt(strings.TrimSpace(hr.Value[start:start+11]), 10, 64)
+				if err != nil {
+					return err
+				}
+				*coordinates[i] = float64(mm) / 1000
+			}
+			h.Stations = append(h.Stations, station)
+			return nil
+		},
+		"PRN LIST": func(_ *scanner.Scanner, h *ClockHeader, hr header.HeaderRecord) error {
+			// Continuation lines repeat the PRN LIST label
+			h.Satellites = append(h.Satellites, strings.Fields(hr.Value)...)
+			return nil
+		},
+	}
+)
+
+func NewClockHeader(header header.Header) ClockHeader {
+	return ClockHeader{Header: header}
+}
+
+func ParseClockHeader(scanner *scanner.Scanner, header *ClockHeader) error {
+	for {
+		hr, err := ParseClockHeaderRecord(scanner, header)
+		if err != nil {
+			return err
+		}
+		if hr.Key == "END OF HEADER" {
+			return nil
+		}
+	}
+}
+
+// ParseClockHeaderRecord parses the next header record into clkHeader,
+// skipping any records which aren't required for reading clock data.
+func ParseClockHeaderRecord(scanner *scanner.Scanner, clkHeader *ClockHeader) (hr header.HeaderRecord, err error) {
+	hr, err = header.ParseHeaderRecordAt(scanner, clkHeader.LabelColumn())
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+
+	if parser, ok := header.HeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, &clkHeader.Header, hr)
+	} else if parser, ok := ClockHeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, clkHeader, hr)
+	}
+
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+	return hr, nil
+}
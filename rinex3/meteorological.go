@@ -0,0 +1,87 @@
+package rinex3
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex/scanner"
+)
+
+// MeteorologicalRecord holds the values observed at a single epoch, keyed by
+// observation type, where blank (missing) values are omitted
+type MeteorologicalRecord struct {
+	Time   time.Time
+	Values map[string]float64
+}
+
+// ParseMeteorologicalRecord parses the next meteorological data record. The
+// epoch is " yy mm dd hh mm ss" for RINEX 2 and " yyyy mm dd hh mm ss" for
+// RINEX 3, followed by up to 8 F7.1 values with continuation lines of 4X,10F7.1
+func ParseMeteorologicalRecord(s *scanner.Scanner, formatVersion float64, observationTypes []string) (record MeteorologicalRecord, err error) {
+	line, err := s.ReadLine()
+	if err != nil {
+		return record, err
+	}
+
+	epochLength := 20
+	if formatVersion < 3 {
+		epochLength = 18
+	}
+	if len(line) < epochLength {
+		return record, fmt.Errorf("invalid meteorological record at line %d", s.Line)
+	}
+
+	record.Time, err = parseMeteorologicalEpoch(line[:epochLength])
+	if err != nil {
+		return record, fmt.Errorf("invalid meteorological record epoch at line %d: %v", s.Line, err)
+	}
+
+	record.Values = map[string]float64{}
+	line = scanner.PadRight(line, epochLength+8*7)
+	for i, obsType := range observationTypes {
+		start := epochLength + 7*i
+		if i >= 8 {
+			if (i-8)%10 == 0 {
+				if line, err = s.ReadLine(); err != nil {
+					return record, err
+				}
+				line = scanner.PadRight(line, 4+10*7)
+			}
+			start = 4 + 7*((i-8)%10)
+		}
+
+		field := strings.TrimSpace(line[start : start+7])
+		if field == "" {
+			continue
+		}
+		if record.Values[obsType], err = strconv.ParseFloat(field, 64); err != nil {
+			return record, fmt.Errorf("invalid meteorological value at line %d: %v", s.Line, err)
+		}
+	}
+
+	return record, nil
+}
+
+func parseMeteorologicalEpoch(epoch string) (t time.Time, err error) {
+	fields := strings.Fields(epoch)
+	if len(fields) != 6 {
+		return t, fmt.Errorf("invalid epoch \"%s\"", epoch)
+	}
+
+	var values [6]int
+	for i, field := range fields {
+		if values[i], err = strconv.Atoi(field); err != nil {
+			return t, err
+		}
+	}
+
+	if len(fields[0]) <= 2 { // Two digit years 80-99 refer to 1980-1999
+		if values[0] += 2000; values[0] >= 2080 {
+			values[0] -= 100
+		}
+	}
+
+	return time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], 0, time.UTC), nil
+}
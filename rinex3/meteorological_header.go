@@ -0,0 +1,149 @@
+package rinex3
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/scanner"
+)
+
+// MeteorologicalHeader is the header of a RINEX 2 or 3 meteorological file,
+// which share the same header records
+type MeteorologicalHeader struct {
+	header.Header
+	Marker struct {
+		Name   string
+		Number string
+	}
+	// Two character observation codes, such as PR (pressure), TD (dry
+	// temperature), HR (relative humidity) and ZW (wet zenith path delay)
+	ObservationTypes []string
+	Sensors          map[string]*MeteorologicalSensor // Keyed by observation type
+}
+
+type MeteorologicalSensor struct {
+	Model    string
+	Type     string
+	Accuracy float64
+	// Position is only defined for some sensors, with Height being the
+	// ellipsoidal height of the sensor
+	Position struct {
+		X      float64
+		Y      float64
+		Z      float64
+		Height float64
+	}
+}
+
+type MeteorologicalHeaderRecordParser func(*scanner.Scanner, *MeteorologicalHeader, header.HeaderRecord) error
+
+var (
+	MeteorologicalHeaderRecordParsers map[string]MeteorologicalHeaderRecordParser = map[string]MeteorologicalHeaderRecordParser{
+		"MARKER NAME": func(_ *scanner.Scanner, h *MeteorologicalHeader, hr header.HeaderRecord) error {
+			h.Marker.Name = strings.TrimSpace(hr.Value)
+			return nil
+		},
+		"MARKER NUMBER": func(_ *scanner.Scanner, h *MeteorologicalHeader, hr header.HeaderRecord) error {
+			h.Marker.Number = strings.TrimSpace(hr.Value[:20])
+			return nil
+		},
+		"# / TYPES OF OBSERV": func(s *scanner.Scanner, h *MeteorologicalHeader, hr header.HeaderRecord) (err error) {
+			totalObs, err := strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+			if err != nil {
+				return err
+			}
+
+			h.ObservationTypes = nil
+			for { // Handle continuation lines, each containing up to 9 types
+				for i := 0; i < 9 && len(h.ObservationTypes) < totalObs; i++ {
+					obsType := strings.TrimSpace(hr.Value[6+(6*i) : 12+(6*i)])
+					if obsType == "" {
+						return HeaderRecordPatternError
+					}
+					h.ObservationTypes = append(h.ObservationTypes, obsType)
+				}
+				if len(h.ObservationTypes) == totalObs {
+					return nil
+				}
+
+				hr, err = header.ParseHeaderRecord(s)
+				if err != nil {
+					return err
+				}
+				if hr.Key != "# / TYPES OF OBSERV" {
+					return HeaderRecordPatternError
+				}
+			}
+		},
+		"SENSOR MOD/TYPE/ACC": func(_ *scanner.Scanner, h *MeteorologicalHeader, hr header.HeaderRecord) (err error) {
+			sensor := h.sensor(hr.Value[57:59])
+			sensor.Model = strings.TrimSpace(hr.Value[:20])
+			sensor.Type = strings.TrimSpace(hr.Value[20:40])
+			sensor.Accuracy, err = ParseFortranFloat(hr.Value[46:53])
+			return err
+		},
+		"SENSOR POS XYZ/H": func(_ *scanner.Scanner, h *MeteorologicalHeader, hr header.HeaderRecord) (err error) {
+			sensor := h.sensor(hr.Value[57:59])
+			if sensor.Position.X, err = ParseFortranFloat(hr.Value[:14]); err != nil {
+				return err
+			}
+			if sensor.Position.Y, err = ParseFortranFloat(hr.Value[14:28]); err != nil {
+				return err
+			}
+			if sensor.Position.Z, err = ParseFortranFloat(hr.Value[28:42]); err != nil {
+				return err
+			}
+			sensor.Position.Height, err = ParseFortranFloat(hr.Value[42:56])
+			return err
+		},
+	}
+)
+
+func NewMeteorologicalHeader(header header.Header) MeteorologicalHeader {
+	return MeteorologicalHeader{
+		Header:  header,
+		Sensors: map[string]*MeteorologicalSensor{},
+	}
+}
+
+func (h *MeteorologicalHeader) sensor(obsType string) *MeteorologicalSensor {
+	obsType = strings.TrimSpace(obsType)
+	if _, ok := h.Sensors[obsType]; !ok {
+		h.Sensors[obsType] = &MeteorologicalSensor{}
+	}
+	return h.Sensors[obsType]
+}
+
+func ParseMeteorologicalHeader(scanner *scanner.Scanner, header *MeteorologicalHeader) error {
+	for {
+		hr, err := ParseMeteorologicalHeaderRecord(scanner, header)
+		if err != nil {
+			return err
+		}
+		if hr.Key == "END OF HEADER" {
+			return nil
+		}
+	}
+}
+
+// ParseMeteorologicalHeaderRecord parses the next header record into
+// metHeader, skipping any records which aren't defined for meteorological
+// files.
+func ParseMeteorologicalHeaderRecord(scanner *scanner.Scanner, metHeader *MeteorologicalHeader) (hr header.HeaderRecord, err error) {
+	hr, err = header.ParseHeaderRecord(scanner)
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+
+	if parser, ok := header.HeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, &metHeader.Header, hr)
+	} else if parser, ok := MeteorologicalHeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, metHeader, hr)
+	}
+
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+	return hr, nil
+}
@@ -0,0 +1,344 @@
+package rinex3
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex/scanner"
+)
+
+// NavigationRecord is a broadcast ephemeris for a single satellite, being one
+// of GPSEphemeris, GalileoEphemeris, GLONASSEphemeris, BeiDouEphemeris,
+// QZSSEphemeris, IRNSSEphemeris or SBASEphemeris
+type NavigationRecord interface {
+	SatelliteID() string
+	Epoch() time.Time
+	// Fields returns pointers to each of the record's values in the order in
+	// which they appear in the file, where nil is a spare field
+	Fields() []*float64
+}
+
+// EphemerisHeader holds the satellite and Time of Clock (in the satellite's
+// system time, or UTC for GLONASS and SBAS) common to all NavigationRecords
+type EphemerisHeader struct {
+	Satellite   string
+	TimeOfClock time.Time
+}
+
+func (e EphemerisHeader) SatelliteID() string {
+	return e.Satellite
+}
+
+func (e EphemerisHeader) Epoch() time.Time {
+	return e.TimeOfClock
+}
+
+// KeplerianOrbit holds the orbital parameters shared by the GPS, Galileo,
+// BeiDou, QZSS and IRNSS broadcast ephemerides
+type KeplerianOrbit struct {
+	Crs      float64
+	DeltaN   float64
+	M0       float64
+	Cuc      float64
+	E        float64
+	Cus      float64
+	SqrtA    float64
+	Toe      float64
+	Cic      float64
+	Omega0   float64
+	Cis      float64
+	I0       float64
+	Crc      float64
+	Omega    float64
+	OmegaDot float64
+	IDOT     float64
+}
+
+type GPSEphemeris struct {
+	EphemerisHeader
+	ClockBias      float64
+	ClockDrift     float64
+	ClockDriftRate float64
+	IODE           float64
+	KeplerianOrbit
+	CodesOnL2        float64
+	Week             float64
+	L2PDataFlag      float64
+	Accuracy         float64
+	Health           float64
+	TGD              float64
+	IODC             float64
+	TransmissionTime float64
+	FitInterval      float64
+}
+
+func (e *GPSEphemeris) Fields() []*float64 {
+	o := &e.KeplerianOrbit
+	return []*float64{
+		&e.ClockBias, &e.ClockDrift, &e.ClockDriftRate,
+		&e.IODE, &o.Crs, &o.DeltaN, &o.M0,
+		&o.Cuc, &o.E, &o.Cus, &o.SqrtA,
+		&o.Toe, &o.Cic, &o.Omega0, &o.Cis,
+		&o.I0, &o.Crc, &o.Omega, &o.OmegaDot,
+		&o.IDOT, &e.CodesOnL2, &e.Week, &e.L2PDataFlag,
+		&e.Accuracy, &e.Health, &e.TGD, &e.IODC,
+		&e.TransmissionTime, &e.FitInterval,
+	}
+}
+
+// QZSSEphemeris has the same layout as GPSEphemeris
+type QZSSEphemeris GPSEphemeris
+
+func (e *QZSSEphemeris) Fields() []*float64 {
+	return (*GPSEphemeris)(e).Fields()
+}
+
+type GalileoEphemeris struct {
+	EphemerisHeader
+	ClockBias      float64
+	ClockDrift     float64
+	ClockDriftRate float64
+	IODNav         float64
+	KeplerianOrbit
+	DataSources      float64
+	Week             float64
+	SISA             float64
+	Health           float64
+	BGDE5aE1         float64
+	BGDE5bE1         float64
+	TransmissionTime float64
+}
+
+func (e *GalileoEphemeris) Fields() []*float64 {
+	o := &e.KeplerianOrbit
+	return []*float64{
+		&e.ClockBias, &e.ClockDrift, &e.ClockDriftRate,
+		&e.IODNav, &o.Crs, &o.DeltaN, &o.M0,
+		&o.Cuc, &o.E, &o.Cus, &o.SqrtA,
+		&o.Toe, &o.Cic, &o.Omega0, &o.Cis,
+		&o.I0, &o.Crc, &o.Omega, &o.OmegaDot,
+		&o.IDOT, &e.DataSources, &e.Week, nil,
+		&e.SISA, &e.Health, &e.BGDE5aE1, &e.BGDE5bE1,
+		&e.TransmissionTime,
+	}
+}
+
+type BeiDouEphemeris struct {
+	EphemerisHeader
+	ClockBias      float64
+	ClockDrift     float64
+	ClockDriftRate float64
+	AODE           float64
+	KeplerianOrbit
+	Week             float64
+	Accuracy         float64
+	SatH1            float64
+	TGD1             float64
+	TGD2             float64
+	TransmissionTime float64
+	AODC             float64
+}
+
+func (e *BeiDouEphemeris) Fields() []*float64 {
+	o := &e.KeplerianOrbit
+	return []*float64{
+		&e.ClockBias, &e.ClockDrift, &e.ClockDriftRate,
+		&e.AODE, &o.Crs, &o.DeltaN, &o.M0,
+		&o.Cuc, &o.E, &o.Cus, &o.SqrtA,
+		&o.Toe, &o.Cic, &o.Omega0, &o.Cis,
+		&o.I0, &o.Crc, &o.Omega, &o.OmegaDot,
+		&o.IDOT, nil, &e.Week, nil,
+		&e.Accuracy, &e.SatH1, &e.TGD1, &e.TGD2,
+		&e.TransmissionTime, &e.AODC,
+	}
+}
+
+type IRNSSEphemeris struct {
+	EphemerisHeader
+	ClockBias      float64
+	ClockDrift     float64
+	ClockDriftRate float64
+	IODEC          float64
+	KeplerianOrbit
+	Week             float64
+	URA              float64
+	Health           float64
+	TGD              float64
+	TransmissionTime float64
+}
+
+func (e *IRNSSEphemeris) Fields() []*float64 {
+	o := &e.KeplerianOrbit
+	return []*float64{
+		&e.ClockBias, &e.ClockDrift, &e.ClockDriftRate,
+		&e.IODEC, &o.Crs, &o.DeltaN, &o.M0,
+		&o.Cuc, &o.E, &o.Cus, &o.SqrtA,
+		&o.Toe, &o.Cic, &o.Omega0, &o.Cis,
+		&o.I0, &o.Crc, &o.Omega, &o.OmegaDot,
+		&o.IDOT, nil, &e.Week, nil,
+		&e.URA, &e.Health, &e.TGD, nil,
+		&e.TransmissionTime,
+	}
+}
+
+// StateVector holds the position (km), velocity (km/s) and lunisolar
+// acceleration (km/s^2) of the GLONASS and SBAS broadcast ephemerides
+type StateVector struct {
+	X, XDot, XAcc float64
+	Y, YDot, YAcc float64
+	Z, ZDot, ZAcc float64
+}
+
+type GLONASSEphemeris struct {
+	EphemerisHeader
+	ClockBias        float64 // -TauN
+	RelativeFreqBias float64 // +GammaN
+	MessageFrameTime float64
+	StateVector
+	Health          float64
+	FrequencyNumber float64
+	AgeOfOperation  float64
+	// Only present from RINEX 3.05
+	StatusFlags    float64
+	L1L2GroupDelay float64
+	URAI           float64
+	HealthFlags    float64
+}
+
+func (e *GLONASSEphemeris) Fields() []*float64 {
+	v := &e.StateVector
+	return []*float64{
+		&e.ClockBias, &e.RelativeFreqBias, &e.MessageFrameTime,
+		&v.X, &v.XDot, &v.XAcc, &e.Health,
+		&v.Y, &v.YDot, &v.YAcc, &e.FrequencyNumber,
+		&v.Z, &v.ZDot, &v.ZAcc, &e.AgeOfOperation,
+		&e.StatusFlags, &e.L1L2GroupDelay, &e.URAI, &e.HealthFlags,
+	}
+}
+
+type SBASEphemeris struct {
+	EphemerisHeader
+	ClockBias        float64 // aGf0
+	ClockDrift       float64 // aGf1
+	TransmissionTime float64
+	StateVector
+	Health float64
+	URA    float64
+	IODN   float64
+}
+
+func (e *SBASEphemeris) Fields() []*float64 {
+	v := &e.StateVector
+	return []*float64{
+		&e.ClockBias, &e.ClockDrift, &e.TransmissionTime,
+		&v.X, &v.XDot, &v.XAcc, &e.Health,
+		&v.Y, &v.YDot, &v.YAcc, &e.URA,
+		&v.Z, &v.ZDot, &v.ZAcc, &e.IODN,
+	}
+}
+
+// NewNavigationRecord returns an empty NavigationRecord of the type used by
+// the given satellite system
+func NewNavigationRecord(system string, eh EphemerisHeader) (NavigationRecord, error) {
+	switch system {
+	case "G":
+		return &GPSEphemeris{EphemerisHeader: eh}, nil
+	case "E":
+		return &GalileoEphemeris{EphemerisHeader: eh}, nil
+	case "R":
+		return &GLONASSEphemeris{EphemerisHeader: eh}, nil
+	case "C":
+		return &BeiDouEphemeris{EphemerisHeader: eh}, nil
+	case "J":
+		return &QZSSEphemeris{EphemerisHeader: eh}, nil
+	case "I":
+		return &IRNSSEphemeris{EphemerisHeader: eh}, nil
+	case "S":
+		return &SBASEphemeris{EphemerisHeader: eh}, nil
+	default:
+		return nil, fmt.Errorf("invalid satellite system \"%s\"", system)
+	}
+}
+
+// ParseNavigationRecord parses the next broadcast ephemeris, consisting of
+// the SV / EPOCH / SV CLK line followed by a number of BROADCAST ORBIT lines
+// which are indented by four spaces. Values are formatted as D19.12.
+func ParseNavigationRecord(s *scanner.Scanner) (record NavigationRecord, err error) {
+	return ParseNavigationRecordVersion(s, 3.05)
+}
+
+// ParseNavigationRecordVersion parses the next broadcast ephemeris of a file
+// with the given format version, where GLONASS ephemerides before version
+// 3.05 have no status line.
+func ParseNavigationRecordVersion(s *scanner.Scanner, formatVersion float64) (record NavigationRecord, err error) {
+	line, err := s.ReadLine()
+	if err != nil {
+		return record, err
+	}
+	line = scanner.PadRight(line, 80)
+
+	eh := EphemerisHeader{Satellite: strings.Replace(line[:3], " ", "0", -1)}
+	eh.TimeOfClock, err = ParseNavigationEpoch(line[4:23])
+	if err != nil {
+		return record, fmt.Errorf("invalid navigation record epoch at line %d: %v", s.Line, err)
+	}
+
+	record, err = NewNavigationRecord(line[:1], eh)
+	if err != nil {
+		return record, fmt.Errorf("%v at line %d", err, s.Line)
+	}
+
+	values := []string{line[23:42], line[42:61], line[61:80]}
+	for {
+		next, err := s.Peek(4)
+		if err != nil || string(next) != "    " {
+			break
+		}
+
+		line, err = s.ReadLine()
+		if err != nil {
+			return record, err
+		}
+		line = scanner.PadRight(line, 80)
+		values = append(values, line[4:23], line[23:42], line[42:61], line[61:80])
+	}
+
+	fields := record.Fields()
+	required := len(fields)
+	if _, ok := record.(*GLONASSEphemeris); ok && formatVersion < 3.05 {
+		required -= 4
+	}
+	if len(values) < required {
+		return record, fmt.Errorf("incomplete navigation record for %s at line %d", eh.Satellite, s.Line)
+	}
+
+	for i, field := range fields {
+		if field == nil || i >= len(values) {
+			continue
+		}
+		if *field, err = ParseFortranFloat(values[i]); err != nil {
+			return record, fmt.Errorf("invalid navigation record value at line %d: %v", s.Line, err)
+		}
+	}
+
+	return record, nil
+}
+
+// ParseNavigationEpoch parses a "yyyy mm dd hh mm ss" navigation epoch
+func ParseNavigationEpoch(epoch string) (t time.Time, err error) {
+	fields := strings.Fields(epoch)
+	if len(fields) != 6 {
+		return t, fmt.Errorf("invalid epoch \"%s\"", epoch)
+	}
+
+	var values [6]int
+	for i, field := range fields {
+		if values[i], err = strconv.Atoi(field); err != nil {
+			return t, err
+		}
+	}
+
+	return time.Date(values[0], time.Month(values[1]), values[2], values[3], values[4], values[5], 0, time.UTC), nil
+}
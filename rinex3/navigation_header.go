@@ -0,0 +1,54 @@
+package rinex3
+
+import (
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/scanner"
+)
+
+type NavigationHeader struct {
+	header.Header
+	// Ionospheric correction parameters keyed by correction type, such as
+	// GAL (ai0-ai2), GPSA (alpha0-alpha3) and GPSB (beta0-beta3)
+	IonosphericCorrections map[string][]float64
+	TimeSystemCorrections  []TimeSystemCorrection
+	LeapSeconds            LeapSeconds
+}
+
+// TimeSystemCorrection is a TIME SYSTEM CORR record, where the correction to
+// transform from one time system to another is a0 + a1 * (t - reference time)
+type TimeSystemCorrection struct {
+	Type          string // e.g. GPUT, GAUT, GLGP
+	A0            float64
+	A1            float64
+	ReferenceTime int // Seconds into the reference week
+	ReferenceWeek int
+	Source        string // e.g. EGNOS, WAAS or SnnN for a SBAS satellite
+	UTCIdentifier int
+}
+
+type LeapSeconds struct {
+	Current    int
+	Future     int // Future or past leap seconds, being Current if not known
+	FutureWeek int
+	FutureDay  int
+	System     string // Blank for GPS, otherwise BDS
+}
+
+func NewNavigationHeader(header header.Header) NavigationHeader {
+	return NavigationHeader{
+		Header:                 header,
+		IonosphericCorrections: map[string][]float64{},
+	}
+}
+
+func ParseNavigationHeader(scanner *scanner.Scanner, header *NavigationHeader) error {
+	for {
+		hr, err := ParseNavigationHeaderRecord(scanner, header)
+		if err != nil {
+			return err
+		}
+		if hr.Key == "END OF HEADER" {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package rinex3
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/scanner"
+)
+
+type NavigationHeaderRecordParser func(*scanner.Scanner, *NavigationHeader, header.HeaderRecord) error
+
+var (
+	NavigationHeaderRecordParsers map[string]NavigationHeaderRecordParser = map[string]NavigationHeaderRecordParser{
+		"IONOSPHERIC CORR": func(_ *scanner.Scanner, h *NavigationHeader, hr header.HeaderRecord) (err error) {
+			correctionType := strings.TrimSpace(hr.Value[:4])
+			numParams := 4
+			if correctionType == "GAL" {
+				numParams = 3
+			}
+
+			params := make([]float64, numParams)
+			for i := range params {
+				params[i], err = ParseFortranFloat(hr.Value[5+(12*i) : 17+(12*i)])
+				if err != nil {
+					return err
+				}
+			}
+			h.IonosphericCorrections[correctionType] = params
+			return nil
+		},
+		"TIME SYSTEM CORR": func(_ *scanner.Scanner, h *NavigationHeader, hr header.HeaderRecord) (err error) {
+			correction := TimeSystemCorrection{
+				Type:   strings.TrimSpace(hr.Value[:4]),
+				Source: strings.TrimSpace(hr.Value[51:56]),
+			}
+			if correction.A0, err = ParseFortranFloat(hr.Value[5:22]); err != nil {
+				return err
+			}
+			if correction.A1, err = ParseFortranFloat(hr.Value[22:38]); err != nil {
+				return err
+			}
+			if correction.ReferenceTime, err = parseOptionalInt(hr.Value[38:45]); err != nil {
+				return err
+			}
+			if correction.ReferenceWeek, err = parseOptionalInt(hr.Value[45:50]); err != nil {
+				return err
+			}
+			if correction.UTCIdentifier, err = parseOptionalInt(hr.Value[56:59]); err != nil {
+				return err
+			}
+			h.TimeSystemCorrections = append(h.TimeSystemCorrections, correction)
+			return nil
+		},
+		"LEAP SECONDS": func(_ *scanner.Scanner, h *NavigationHeader, hr header.HeaderRecord) (err error) {
+			h.LeapSeconds, err = ParseLeapSeconds(hr.Value)
+			return err
+		},
+	}
+)
+
+// ParseLeapSeconds parses the value of a RINEX 3 LEAP SECONDS record, where
+// only the current number of leap seconds is mandatory
+func ParseLeapSeconds(value string) (ls LeapSeconds, err error) {
+	if ls.Current, err = strconv.Atoi(strings.TrimSpace(value[:6])); err != nil {
+		return ls, err
+	}
+	if ls.Future, err = parseOptionalInt(value[6:12]); err != nil {
+		return ls, err
+	}
+	if strings.TrimSpace(value[6:12]) == "" {
+		ls.Future = ls.Current
+	}
+	if ls.FutureWeek, err = parseOptionalInt(value[12:18]); err != nil {
+		return ls, err
+	}
+	if ls.FutureDay, err = parseOptionalInt(value[18:24]); err != nil {
+		return ls, err
+	}
+	ls.System = strings.TrimSpace(value[24:27])
+	return ls, nil
+}
+
+// ParseNavigationHeaderRecord parses the next header record into navHeader.
+// Records which aren't required for navigation data, such as MERGED FILE,
+// DOI or receiver specific labels, are skipped.
+func ParseNavigationHeaderRecord(scanner *scanner.Scanner, navHeader *NavigationHeader) (hr header.HeaderRecord, err error) {
+	hr, err = header.ParseHeaderRecord(scanner)
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+
+	if parser, ok := header.HeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, &navHeader.Header, hr)
+	} else if parser, ok := NavigationHeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, navHeader, hr)
+	}
+
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+	return hr, nil
+}
+
+// ParseFortranFloat parses a floating point field which may use a Fortran
+// style "D" exponent (e.g. D19.12), where a blank field is zero
+func ParseFortranFloat(field string) (float64, error) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return 0, nil
+	}
+	field = strings.Map(func(r rune) rune {
+		if r == 'D' || r == 'd' {
+			return 'E'
+		}
+		return r
+	}, field)
+	return strconv.ParseFloat(field, 64)
+}
+
+func parseOptionalInt(field string) (int, error) {
+	field = strings.TrimSpace(field)
+	if field == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(field)
+}
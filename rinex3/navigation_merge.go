@@ -0,0 +1,189 @@
+package rinex3
+
+import (
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Ephemeris is a NavigationRecord which is a broadcast ephemeris, identified
+// by its satellite, Time of Clock, time of ephemeris and issue of data
+type Ephemeris interface {
+	NavigationRecord
+	// TimeOfEphemeris is the Toe of the orbit in seconds of the week, or zero
+	// for GLONASS and SBAS which are referenced to the Time of Clock
+	TimeOfEphemeris() float64
+	// IssueOfData returns the values identifying the ephemeris data set, such
+	// as IODE and IODC for GPS
+	IssueOfData() []float64
+	// TransmissionTimeField returns a pointer to the transmission time of the
+	// message, which varies between receivers for the same ephemeris
+	TransmissionTimeField() *float64
+}
+
+func (o KeplerianOrbit) TimeOfEphemeris() float64 {
+	return o.Toe
+}
+
+func (e *GPSEphemeris) IssueOfData() []float64 {
+	return []float64{e.IODE, e.IODC}
+}
+
+func (e *GPSEphemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
+
+func (e *QZSSEphemeris) IssueOfData() []float64 {
+	return (*GPSEphemeris)(e).IssueOfData()
+}
+
+func (e *QZSSEphemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
+
+// The data sources distinguish I/NAV and F/NAV ephemerides with the same IODnav
+func (e *GalileoEphemeris) IssueOfData() []float64 {
+	return []float64{e.IODNav, e.DataSources}
+}
+
+func (e *GalileoEphemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
+
+func (e *BeiDouEphemeris) IssueOfData() []float64 {
+	return []float64{e.AODE, e.AODC}
+}
+
+func (e *BeiDouEphemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
+
+func (e *IRNSSEphemeris) IssueOfData() []float64 {
+	return []float64{e.IODEC}
+}
+
+func (e *IRNSSEphemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
+
+func (e *GLONASSEphemeris) TimeOfEphemeris() float64 {
+	return 0
+}
+
+// GLONASS ephemerides have no issue of data, being identified by their Time
+// of Clock (tb)
+func (e *GLONASSEphemeris) IssueOfData() []float64 {
+	return nil
+}
+
+func (e *GLONASSEphemeris) TransmissionTimeField() *float64 {
+	return &e.MessageFrameTime
+}
+
+func (e *SBASEphemeris) TimeOfEphemeris() float64 {
+	return 0
+}
+
+func (e *SBASEphemeris) IssueOfData() []float64 {
+	return []float64{e.IODN}
+}
+
+func (e *SBASEphemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
+
+type ephemerisKey struct {
+	satellite   string
+	timeOfClock time.Time
+	toe         float64
+	issueOfData [4]float64
+}
+
+// recordKey identifies the other records which may be identical
+type recordKey struct {
+	recordType reflect.Type
+	satellite  string
+	epoch      time.Time
+}
+
+// MergeNavigationRecords merges the NavigationRecords of several files, such
+// as the daily navigation files of a number of stations, into a single list
+// ordered by satellite and epoch.
+//
+// Ephemerides are matched by satellite, Time of Clock, time of ephemeris and
+// issue of data, and those which differ only in transmission time are
+// dropped, keeping the earliest transmission. Matching ephemerides with any
+// other differences are all kept. Other records (such as the RINEX 4 STO,
+// EOP and ION records) are only dropped if they are identical.
+func MergeNavigationRecords(files ...[]NavigationRecord) (merged []NavigationRecord) {
+	ephemerides := map[ephemerisKey][]int{} // Indexes into merged
+	others := map[recordKey][]int{}
+
+	for _, records := range files {
+	records:
+		for _, record := range records {
+			ephemeris, ok := record.(Ephemeris)
+			if !ok {
+				key := recordKey{reflect.TypeOf(record), record.SatelliteID(), record.Epoch()}
+				for _, i := range others[key] {
+					if reflect.DeepEqual(merged[i], record) {
+						continue records
+					}
+				}
+				others[key] = append(others[key], len(merged))
+				merged = append(merged, record)
+				continue
+			}
+
+			key := ephemerisKey{
+				satellite:   record.SatelliteID(),
+				timeOfClock: record.Epoch(),
+				toe:         ephemeris.TimeOfEphemeris(),
+			}
+			copy(key.issueOfData[:], ephemeris.IssueOfData())
+
+			for _, i := range ephemerides[key] {
+				existing := merged[i].(Ephemeris)
+				if !equalExceptTransmissionTime(existing, ephemeris) {
+					continue
+				}
+				if *ephemeris.TransmissionTimeField() < *existing.TransmissionTimeField() {
+					merged[i] = record
+				}
+				continue records
+			}
+
+			ephemerides[key] = append(ephemerides[key], len(merged))
+			merged = append(merged, record)
+		}
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		if a, b := merged[i].SatelliteID(), merged[j].SatelliteID(); a != b {
+			return a < b
+		}
+		return merged[i].Epoch().Before(merged[j].Epoch())
+	})
+	return merged
+}
+
+func equalExceptTransmissionTime(a, b Ephemeris) bool {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+
+	aFields, bFields := a.Fields(), b.Fields()
+	if len(aFields) != len(bFields) {
+		return false
+	}
+	skip := a.TransmissionTimeField()
+	for i := range aFields {
+		if aFields[i] == nil || aFields[i] == skip {
+			continue
+		}
+		if *aFields[i] != *bFields[i] {
+			return false
+		}
+	}
+	return true
+}
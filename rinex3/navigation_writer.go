@@ -0,0 +1,156 @@
+package rinex3
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex/header"
+)
+
+// NavigationWriter writes a RINEX 3 navigation file to an io.Writer one
+// record at a time. RINEX 3.05 files include the fourth BROADCAST ORBIT line
+// of GLONASS ephemerides, which is omitted for earlier versions.
+type NavigationWriter struct {
+	w      io.Writer
+	Header NavigationHeader
+}
+
+// NewNavigationWriter writes the header records of h to w, and returns a
+// NavigationWriter for writing the records which follow.
+func NewNavigationWriter(w io.Writer, h NavigationHeader) (*NavigationWriter, error) {
+	writer := &NavigationWriter{w, h}
+	_, err := io.WriteString(w, strings.Join(FormatNavigationHeader(h), ""))
+	return writer, err
+}
+
+// WriteRecord writes a NavigationRecord, which must have the layout of one
+// of the RINEX 3 ephemeris types.
+func (w *NavigationWriter) WriteRecord(record NavigationRecord) error {
+	text, err := FormatNavigationRecord(record, w.Header.FormatVersion)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w.w, text)
+	return err
+}
+
+// FormatNavigationHeader formats the records of a NavigationHeader as lines,
+// with the ionospheric corrections ordered by correction type.
+func FormatNavigationHeader(h NavigationHeader) (lines []string) {
+	lines = FormatHeader(h.Header, "N: GNSS NAV DATA")
+
+	types := make([]string, 0, len(h.IonosphericCorrections))
+	for correctionType := range h.IonosphericCorrections {
+		types = append(types, correctionType)
+	}
+	sort.Strings(types)
+	for _, correctionType := range types {
+		value := fmt.Sprintf("%-4.4s ", correctionType)
+		for _, param := range h.IonosphericCorrections[correctionType] {
+			value += FormatFortranFloat(param, 12, 4, "D")
+		}
+		lines = append(lines, header.FormatHeaderRecord(value, "IONOSPHERIC CORR"))
+	}
+
+	for _, c := range h.TimeSystemCorrections {
+		value := fmt.Sprintf("%-4.4s %s%s %6d %4d %-5.5s %2d", c.Type,
+			FormatFortranFloat(c.A0, 17, 10, "D"), FormatFortranFloat(c.A1, 16, 9, "D"),
+			c.ReferenceTime, c.ReferenceWeek, c.Source, c.UTCIdentifier)
+		lines = append(lines, header.FormatHeaderRecord(value, "TIME SYSTEM CORR"))
+	}
+
+	if h.LeapSeconds.Current != 0 {
+		lines = append(lines, header.FormatHeaderRecord(FormatLeapSeconds(h.LeapSeconds), "LEAP SECONDS"))
+	}
+
+	return append(lines, header.FormatHeaderRecord("", "END OF HEADER"))
+}
+
+// FormatLeapSeconds formats the value of a LEAP SECONDS record, omitting
+// the future leap seconds if they aren't known.
+func FormatLeapSeconds(ls LeapSeconds) string {
+	if ls.Future == ls.Current && ls.FutureWeek == 0 && ls.FutureDay == 0 && ls.System == "" {
+		return fmt.Sprintf("%6d", ls.Current)
+	}
+	return fmt.Sprintf("%6d%6d%6d%6d%-3.3s", ls.Current, ls.Future, ls.FutureWeek, ls.FutureDay, ls.System)
+}
+
+// FormatNavigationRecord formats a NavigationRecord as its SV / EPOCH / SV
+// CLK line followed by the BROADCAST ORBIT lines, with values in D19.12.
+func FormatNavigationRecord(record NavigationRecord, formatVersion float64) (string, error) {
+	satellite := record.SatelliteID()
+	expected, err := NewNavigationRecord(satellite[:1], EphemerisHeader{})
+	if err != nil {
+		return "", err
+	}
+
+	fields := record.Fields()
+	if len(fields) != len(expected.Fields()) {
+		return "", fmt.Errorf("%s record of type %T can't be written to a RINEX 3 file", satellite, record)
+	}
+	if _, ok := expected.(*GLONASSEphemeris); ok && formatVersion < 3.05 {
+		fields = fields[:len(fields)-4]
+	}
+
+	return FormatNavigationLines(satellite+" "+FormatNavigationEpoch(record.Epoch()), fields, "D"), nil
+}
+
+// FormatNavigationLines formats the fields of a navigation record, with the
+// first three following the 23 column prefix of the first line, and four on
+// each following line indented by four spaces. Spare fields are written as
+// blanks, and each value uses the given exponent character ("D" or "E").
+func FormatNavigationLines(prefix string, fields []*float64, exponent string) string {
+	var b strings.Builder
+	line := fmt.Sprintf("%-23s", prefix)
+	for i, field := range fields {
+		if i == 3 || (i > 3 && (i-3)%4 == 0) {
+			b.WriteString(strings.TrimRight(line, " ") + "\n")
+			line = strings.Repeat(" ", 4)
+		}
+		if field == nil {
+			line += strings.Repeat(" ", 19)
+		} else {
+			line += FormatFortranFloat(*field, 19, 12, exponent)
+		}
+	}
+	b.WriteString(strings.TrimRight(line, " ") + "\n")
+	return b.String()
+}
+
+// FormatNavigationEpoch formats a navigation epoch as "yyyy mm dd hh mm ss".
+func FormatNavigationEpoch(t time.Time) string {
+	return t.Format("2006 01 02 15 04 05")
+}
+
+// FormatFortranFloat formats a value in a field of the given width with the
+// given number of digits after the decimal point, as with the Fortran D and E
+// edit descriptors, where exponent is the exponent character to use.
+//
+// Exponents are limited to two digits, so values too small for them are
+// written as zero, and values too large for them (including infinities) are
+// saturated to the largest value which can be written, e.g. 9.999E+99. NaN
+// has no representation, so it's written as a blank field.
+func FormatFortranFloat(value float64, width, precision int, exponent string) string {
+	if math.IsNaN(value) {
+		return strings.Repeat(" ", width)
+	}
+	if value != 0 && math.Abs(value) < 1e-99 {
+		value = 0
+	}
+	formatted := fmt.Sprintf("%.*E", precision, value)
+	if math.IsInf(value, 0) || len(formatted)-strings.Index(formatted, "E") > 4 {
+		formatted = "9"
+		if precision > 0 {
+			formatted += "." + strings.Repeat("9", precision)
+		}
+		formatted += "E+99"
+		if value < 0 {
+			formatted = "-" + formatted
+		}
+	}
+	return fmt.Sprintf("%*s", width, strings.Replace(formatted, "E", exponent, 1))
+}
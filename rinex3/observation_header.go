@@ -1,6 +1,13 @@
 package rinex3
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-gnss/rinex/gnss"
 	"github.com/go-gnss/rinex/header"
 	"github.com/go-gnss/rinex/scanner"
 )
@@ -31,52 +38,242 @@ type ObservationHeader struct {
 		Height float64
 		East   float64
 		North  float64
-		// TODO: Figure out how to deal with body-fixed vs fixed station
-		//X      float64
-		//Y      float64
-		//Z      float64
-		//PhaseCenter map[string]map[string]struct {
-		//	// TODO: Some triple type - spec says can be XYZ (body-fixed) or NEU (fixed station)
-		//}
-		//BSight struct {
-		//	X float64
-		//	Y float64
-		//	Z float64
-		//}
-		//ZeroDirection struct {
-		//	Azimuth float64
-		//	X       float64
-		//	Y       float64
-		//	Z       float64
-		//}
+		// Body-fixed antenna position of a vehicle, and the position of each
+		// phase center relative to it, which is XYZ for a vehicle or NEU for
+		// a fixed station
+		DeltaXYZ             XYZ
+		PhaseCenters         []PhaseCenter
+		BoreSight            XYZ
+		ZeroDirectionAzimuth float64
+		ZeroDirection        XYZ
 	}
-	//CenterOfMass struct {
-	//	X float64
-	//	Y float64
-	//	Z float64
-	//}
-	ObservationTypes     map[string][]string // TODO: map[SatelliteSystem][]ObservationType
+	CenterOfMass         XYZ
+	ObservationTypes     map[gnss.SatelliteSystem][]gnss.ObservationCode
 	SignalStrength       string
 	Interval             float64
-	TimeOfFirstObs       Time
-	TimeOfLastObs        Time
-	PhaseShifts          map[string][]float64
-	GLONASSCodePhaseBias map[string]float64 // TODO: map[Signal]float64
+	TimeOfFirstObs       gnss.Time
+	TimeOfLastObs        gnss.Time
+	ClockOffsetApplied   bool
+	DCBsApplied          []AppliedCorrection
+	PCVsApplied          []AppliedCorrection
+	ScaleFactors         []ScaleFactor
+	PhaseShifts          []PhaseShift
+	GLONASSSlots         map[gnss.SatelliteID]int // Frequency number of each satellite
+	GLONASSCodePhaseBias map[gnss.ObservationCode]float64
+	LeapSeconds          LeapSeconds
+	NumSatellites        int
+	ObservationCounts    map[gnss.SatelliteID][]int // Number of each observation type
+	// UnknownRecords are records with unknown labels, and Warnings are the
+	// violations of the header format, when parsed in Lenient mode
+	UnknownRecords []header.HeaderRecord
+	Warnings       []header.RecordError
+}
+
+// XYZ is a position or direction in a cartesian coordinate system.
+type XYZ struct {
+	X float64
+	Y float64
+	Z float64
+}
+
+// PhaseCenter is an ANTENNA: PHASECENTER record, being the average phase
+// center position of an observation type.
+type PhaseCenter struct {
+	System          gnss.SatelliteSystem
+	ObservationType gnss.ObservationCode
+	Position        XYZ
+}
+
+// AppliedCorrection is a SYS / DCBS APPLIED or SYS / PCVS APPLIED record,
+// giving the program used to apply the corrections and their source.
+type AppliedCorrection struct {
+	System  gnss.SatelliteSystem
+	Program string
+	Source  string
+}
+
+// ScaleFactor is a SYS / SCALE FACTOR record, where the observations of the
+// given types were multiplied by the Factor (1, 10, 100 or 1000) before being
+// stored. No ObservationTypes means all types of the system.
+type ScaleFactor struct {
+	System           gnss.SatelliteSystem
+	Factor           int
+	ObservationTypes []gnss.ObservationCode
+}
+
+// PhaseShift is a SYS / PHASE SHIFT record, giving the correction in cycles
+// applied to the phase of an observation type to align it with the reference
+// signal. No Satellites means all satellites of the system, and a zero
+// ObservationType means the phase shifts of the system are unknown.
+type PhaseShift struct {
+	System          gnss.SatelliteSystem
+	ObservationType gnss.ObservationCode
+	Correction      float64
+	Satellites      []gnss.SatelliteID
 }
 
 func NewObservationHeader(header header.Header) ObservationHeader {
 	return ObservationHeader{
 		Header:               header,
-		ObservationTypes:     map[string][]string{},
-		PhaseShifts:          map[string][]float64{},
-		GLONASSCodePhaseBias: map[string]float64{},
+		ObservationTypes:     map[gnss.SatelliteSystem][]gnss.ObservationCode{},
+		GLONASSSlots:         map[gnss.SatelliteID]int{},
+		GLONASSCodePhaseBias: map[gnss.ObservationCode]float64{},
+		ObservationCounts:    map[gnss.SatelliteID][]int{},
+	}
+}
+
+// WithRecords returns a copy of the header with header records applied, such
+// as those of a special event in the data section, which replace the values
+// of the records with the same labels. Records with unknown labels are
+// skipped, apart from being added to Records along with the others, as
+// with header.UpdateHeaderRecords. The observation types of a satellite
+// system replace only those of that system.
+func (h ObservationHeader) WithRecords(records []header.HeaderRecord) (ObservationHeader, error) {
+	h.copyMaps()
+	h.Records = header.UpdateHeaderRecords(h.Records, records, appendedRecords...)
+	s := &scanner.Scanner{Reader: bufio.NewReader(strings.NewReader(header.FormatHeaderRecords(records)))}
+	for {
+		hr, err := header.ParseHeaderRecord(s)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return h, err
+		}
+		_, known := header.HeaderRecordParsers[hr.Key]
+		if _, ok := ObservationHeaderRecordParsers[hr.Key]; !known && !ok {
+			continue
+		}
+		if err = parseObservationHeaderRecord(s, &h, hr); err != nil {
+			return h, header.NewHeaderRecordParsingError(err, records[0].Line+s.Line-1)
+		}
+	}
+
+	for _, hr := range records {
+		if hr.Key == "SYS / # / OBS TYPES" {
+			var lines []string
+			for _, system := range SortSatelliteSystems(h.ObservationTypes) {
+				lines = append(lines, FormatObservationTypes(system, h.ObservationTypes[system])...)
+			}
+			h.Records = header.ReplaceHeaderRecords(h.Records, hr.Key, lines)
+			break
+		}
+	}
+	return h, nil
+}
+
+// appendedRecords are the labels of records which add to the values of the
+// header, rather than replacing them
+var appendedRecords []string = []string{
+	"ANTENNA: PHASECENTER", "SYS / DCBS APPLIED", "SYS / PCVS APPLIED", "SYS / SCALE FACTOR",
+	"SYS / PHASE SHIFT", "GLONASS SLOT / FRQ #", "PRN / # OF OBS",
+}
+
+// copyMaps replaces the maps of the header with copies, and limits the
+// capacity of the slices which records are appended to, so that records can
+// be applied to a copy of the header without changing the original
+func (h *ObservationHeader) copyMaps() {
+	h.Comments = h.Comments[:len(h.Comments):len(h.Comments)]
+	h.Antenna.PhaseCenters = h.Antenna.PhaseCenters[:len(h.Antenna.PhaseCenters):len(h.Antenna.PhaseCenters)]
+	h.DCBsApplied = h.DCBsApplied[:len(h.DCBsApplied):len(h.DCBsApplied)]
+	h.PCVsApplied = h.PCVsApplied[:len(h.PCVsApplied):len(h.PCVsApplied)]
+	h.ScaleFactors = h.ScaleFactors[:len(h.ScaleFactors):len(h.ScaleFactors)]
+	h.PhaseShifts = h.PhaseShifts[:len(h.PhaseShifts):len(h.PhaseShifts)]
+	observationTypes := map[gnss.SatelliteSystem][]gnss.ObservationCode{}
+	for system, types := range h.ObservationTypes {
+		observationTypes[system] = types
+	}
+	h.ObservationTypes = observationTypes
+	slots := map[gnss.SatelliteID]int{}
+	for satellite, slot := range h.GLONASSSlots {
+		slots[satellite] = slot
+	}
+	h.GLONASSSlots = slots
+	biases := map[gnss.ObservationCode]float64{}
+	for code, bias := range h.GLONASSCodePhaseBias {
+		biases[code] = bias
+	}
+	h.GLONASSCodePhaseBias = biases
+	counts := map[gnss.SatelliteID][]int{}
+	for satellite, count := range h.ObservationCounts {
+		counts[satellite] = count
 	}
+	h.ObservationCounts = counts
+}
+
+// TimeSystem returns the time system of the epochs of the file, being that of
+// TIME OF FIRST OBS, which defaults to the time system of the satellite
+// system of a single system file, or GPS time if it's missing from a mixed
+// file.
+func (h ObservationHeader) TimeSystem() gnss.TimeSystem {
+	if system := h.TimeOfFirstObs.System(); system != "" {
+		return system
+	}
+	if system := gnss.SatelliteSystem(h.SatelliteSystem).TimeSystem(); system != "" {
+		return system
+	}
+	return gnss.GPSTime
+}
+
+// ParseObservationHeader parses the header records following RINEX VERSION
+// / TYPE in Strict mode.
+func ParseObservationHeader(scanner *scanner.Scanner, obsHeader *ObservationHeader) error {
+	return ParseObservationHeaderMode(scanner, obsHeader, header.Strict)
 }
 
-func ParseObservationHeader(scanner *scanner.Scanner, header *ObservationHeader) (err error) {
-	hr, err := ParseObservationHeaderRecord(scanner, header)
-	for err != nil || hr.Key != "END OF HEADER" {
-		hr, err = ParseObservationHeaderRecord(scanner, header)
+// ParseObservationHeaderMode parses the header records following RINEX
+// VERSION / TYPE up to END OF HEADER. Invalid records, unknown labels and
+// violations of the ordering rules are returned as a header.RecordError in
+// Strict mode, or added to the header's Warnings in Lenient mode. Reaching
+// the end of the input before END OF HEADER is an error in either mode.
+func ParseObservationHeaderMode(s *scanner.Scanner, obsHeader *ObservationHeader, mode header.ParseMode) error {
+	var order header.RecordOrder
+	violation := func(key string, err error) error {
+		recordErr := header.RecordError{Line: s.Line, Key: key, Err: err}
+		if mode == header.Strict {
+			return recordErr
+		}
+		obsHeader.Warnings = append(obsHeader.Warnings, recordErr)
+		return nil
+	}
+
+	for {
+		line := s.Line
+		hr, err := header.ParseHeaderRecord(s)
+		if err == io.EOF {
+			return header.RecordError{Line: s.Line, Err: errors.New("missing \"END OF HEADER\"")}
+		} else if err != nil && s.Line == line {
+			return err // Failed to read the line
+		} else if err != nil {
+			if err := violation("", err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := order.Check(hr); err != nil {
+			if err := violation(hr.Key, err); err != nil {
+				return err
+			}
+		}
+		if hr.Key == "END OF HEADER" {
+			return nil
+		}
+
+		_, common := header.HeaderRecordParsers[hr.Key]
+		_, known := ObservationHeaderRecordParsers[hr.Key]
+		if !common && !known {
+			if err := violation(hr.Key, fmt.Errorf("invalid header label \"%s\"", hr.Key)); err != nil {
+				return err
+			}
+			obsHeader.UnknownRecords = append(obsHeader.UnknownRecords, hr)
+			continue
+		}
+
+		if err := parseObservationHeaderRecord(s, obsHeader, hr); err != nil {
+			if err := violation(hr.Key, err); err != nil {
+				return err
+			}
+		}
 	}
-	return err
 }
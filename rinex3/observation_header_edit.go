@@ -0,0 +1,106 @@
+package rinex3
+
+import (
+	"strings"
+
+	"github.com/go-gnss/rinex/header"
+)
+
+// ObservationHeaderEdit is a set of changes to the fields of an
+// ObservationHeader, where nil fields are left unchanged.
+type ObservationHeaderEdit struct {
+	MarkerName      *string `json:"marker_name,omitempty" yaml:"marker_name,omitempty"`
+	MarkerNumber    *string `json:"marker_number,omitempty" yaml:"marker_number,omitempty"`
+	ReceiverNumber  *string `json:"receiver_number,omitempty" yaml:"receiver_number,omitempty"`
+	ReceiverType    *string `json:"receiver_type,omitempty" yaml:"receiver_type,omitempty"`
+	ReceiverVersion *string `json:"receiver_version,omitempty" yaml:"receiver_version,omitempty"`
+	AntennaNumber   *string `json:"antenna_number,omitempty" yaml:"antenna_number,omitempty"`
+	AntennaType     *string `json:"antenna_type,omitempty" yaml:"antenna_type,omitempty"`
+	// Antenna height, east and north eccentricities of ANTENNA: DELTA H/E/N
+	AntennaDelta *[3]float64 `json:"antenna_delta_hen,omitempty" yaml:"antenna_delta_hen,omitempty"`
+}
+
+// editableRecords are the labels of the records changed by an
+// ObservationHeaderEdit, in the order they are written
+var editableRecords []string = []string{
+	"MARKER NAME", "MARKER NUMBER", "REC # / TYPE / VERS", "ANT # / TYPE", "ANTENNA: DELTA H/E/N",
+}
+
+// Apply sets the fields of h which are given by the edit, and returns the
+// labels of the records which were changed.
+func (e ObservationHeaderEdit) Apply(h *ObservationHeader) (changed []string) {
+	edited := map[string]bool{}
+	set := func(field *string, value *string, key string) {
+		if value != nil && *field != *value {
+			*field = *value
+			edited[key] = true
+		}
+	}
+
+	set(&h.Marker.Name, e.MarkerName, "MARKER NAME")
+	set(&h.Marker.Number, e.MarkerNumber, "MARKER NUMBER")
+	set(&h.Receiver.Number, e.ReceiverNumber, "REC # / TYPE / VERS")
+	set(&h.Receiver.Type, e.ReceiverType, "REC # / TYPE / VERS")
+	set(&h.Receiver.Version, e.ReceiverVersion, "REC # / TYPE / VERS")
+	set(&h.Antenna.Number, e.AntennaNumber, "ANT # / TYPE")
+	set(&h.Antenna.Type, e.AntennaType, "ANT # / TYPE")
+	if e.AntennaDelta != nil && *e.AntennaDelta != [3]float64{h.Antenna.Height, h.Antenna.East, h.Antenna.North} {
+		h.Antenna.Height, h.Antenna.East, h.Antenna.North = e.AntennaDelta[0], e.AntennaDelta[1], e.AntennaDelta[2]
+		edited["ANTENNA: DELTA H/E/N"] = true
+	}
+
+	for _, key := range editableRecords {
+		if edited[key] {
+			h.UpdateRecords(key)
+			changed = append(changed, key)
+		}
+	}
+	return changed
+}
+
+// SetMarkerName sets the MARKER NAME of h.
+func (h *ObservationHeader) SetMarkerName(name string) {
+	h.Marker.Name = name
+	h.UpdateRecords("MARKER NAME")
+}
+
+// SetMarkerNumber sets the MARKER NUMBER of h.
+func (h *ObservationHeader) SetMarkerNumber(number string) {
+	h.Marker.Number = number
+	h.UpdateRecords("MARKER NUMBER")
+}
+
+// SetReceiver sets the REC # / TYPE / VERS of h.
+func (h *ObservationHeader) SetReceiver(number, receiverType, version string) {
+	h.Receiver.Number, h.Receiver.Type, h.Receiver.Version = number, receiverType, version
+	h.UpdateRecords("REC # / TYPE / VERS")
+}
+
+// SetAntenna sets the ANT # / TYPE of h.
+func (h *ObservationHeader) SetAntenna(number, antennaType string) {
+	h.Antenna.Number, h.Antenna.Type = number, antennaType
+	h.UpdateRecords("ANT # / TYPE")
+}
+
+// SetAntennaDelta sets the ANTENNA: DELTA H/E/N of h.
+func (h *ObservationHeader) SetAntennaDelta(height, east, north float64) {
+	h.Antenna.Height, h.Antenna.East, h.Antenna.North = height, east, north
+	h.UpdateRecords("ANTENNA: DELTA H/E/N")
+}
+
+// UpdateRecords replaces the Records with the given label by formatting the
+// fields of h, after they have been changed. Other records are left as they
+// were read, so that only the edited lines of the header differ.
+func (h *ObservationHeader) UpdateRecords(key string) {
+	if len(h.Records) == 0 {
+		return // FormatObservationHeader is used for headers not read from a file
+	}
+
+	var lines []string
+	for _, line := range FormatObservationHeader(*h) {
+		if strings.TrimSpace(line[60:]) == key {
+			lines = append(lines, line)
+		}
+	}
+	h.Records = header.ReplaceHeaderRecords(h.Records, key, lines)
+}
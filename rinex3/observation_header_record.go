@@ -3,11 +3,13 @@ package rinex3
 import (
 	"errors"
 	"fmt"
+	"github.com/go-gnss/rinex/gnss"
 	"github.com/go-gnss/rinex/header"
 	"github.com/go-gnss/rinex/scanner"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type ObservationHeaderRecordParser func(*scanner.Scanner, *ObservationHeader, header.HeaderRecord) error
@@ -71,22 +73,45 @@ var (
 			return err
 		},
 		"ANTENNA: DELTA X/Y/Z": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			h.Antenna.DeltaXYZ, err = parseXYZ(hr.Value)
 			return err
 		},
 		"ANTENNA: PHASECENTER": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			var pc PhaseCenter
+			if pc.System, err = gnss.ParseSatelliteSystem(hr.Value[:1]); err != nil {
+				return err
+			}
+			if pc.ObservationType, err = gnss.ParseObservationCode(hr.Value[2:5]); err != nil {
+				return err
+			}
+			// The first coordinate is F9.4 rather than F14.4
+			if pc.Position.X, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[5:14]), 64); err != nil {
+				return err
+			}
+			if pc.Position.Y, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[14:28]), 64); err != nil {
+				return err
+			}
+			if pc.Position.Z, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[28:42]), 64); err != nil {
+				return err
+			}
+			h.Antenna.PhaseCenters = append(h.Antenna.PhaseCenters, pc)
+			return nil
 		},
 		"ANTENNA: B.SIGHT XYZ": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			h.Antenna.BoreSight, err = parseXYZ(hr.Value)
+			return err
 		},
 		"ANTENNA: ZERODIR AZI": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			h.Antenna.ZeroDirectionAzimuth, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[:14]), 64)
+			return err
 		},
 		"ANTENNA: ZERODIR XYZ": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			h.Antenna.ZeroDirection, err = parseXYZ(hr.Value)
+			return err
 		},
 		"CENTER OF MASS: XYZ": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			h.CenterOfMass, err = parseXYZ(hr.Value)
+			return err
 		},
 		"SYS / # / OBS TYPES": func(s *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
 			linePattern := regexp.MustCompile(`^([A-Z])..([ 0-9][ 0-9][0-9])|( ([A-Z0-9]{3}))`)
@@ -97,15 +122,24 @@ var (
 				return HeaderRecordPatternError
 			}
 
-			system := matchLine[0][1]
+			system, err := gnss.ParseSatelliteSystem(matchLine[0][1])
+			if err != nil {
+				return err
+			}
 			totalObs, err := strconv.ParseInt(strings.TrimSpace(matchLine[0][2]), 10, 64)
 			if err != nil {
 				return HeaderRecordPatternError
 			}
 
+			h.ObservationTypes[system] = nil // Replaced by header records of events
+
 			for { // Handle continuation lines
 				for _, obs := range matchLine[1:] {
-					h.ObservationTypes[system] = append(h.ObservationTypes[system], strings.TrimSpace(obs[0]))
+					code, err := gnss.ParseObservationCode(obs[0])
+					if err != nil {
+						return err
+					}
+					h.ObservationTypes[system] = append(h.ObservationTypes[system], code)
 				}
 				if len(h.ObservationTypes[system]) < int(totalObs) {
 					line, err := header.ParseHeaderRecord(s)
@@ -139,22 +173,94 @@ var (
 			return err
 		},
 		"RCV CLOCK OFFS APPL": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			applied, err := strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+			h.ClockOffsetApplied = applied == 1
+			return err
 		},
 		"SYS / DCBS APPLIED": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			correction, err := parseAppliedCorrection(hr.Value)
+			h.DCBsApplied = append(h.DCBsApplied, correction)
+			return err
 		},
 		"SYS / PCVS APPLIED": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			correction, err := parseAppliedCorrection(hr.Value)
+			h.PCVsApplied = append(h.PCVsApplied, correction)
+			return err
 		},
-		"SYS / SCALE FACTOR": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+		"SYS / SCALE FACTOR": func(s *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			var sf ScaleFactor
+			if sf.System, err = gnss.ParseSatelliteSystem(hr.Value[:1]); err != nil {
+				return err
+			}
+			if sf.Factor, err = strconv.Atoi(strings.TrimSpace(hr.Value[2:6])); err != nil {
+				return err
+			}
+			numTypes, err := parseOptionalInt(hr.Value[8:10])
+			if err != nil {
+				return err
+			}
+			types, err := parseListRecord(s, hr, 10, 4, 12, numTypes, numTypes != 0)
+			if err != nil {
+				return err
+			}
+			if sf.ObservationTypes, err = parseObservationCodes(types); err != nil {
+				return err
+			}
+			h.ScaleFactors = append(h.ScaleFactors, sf)
+			return nil
 		},
 		"SYS / PHASE SHIFT": func(s *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err
+			var ps PhaseShift
+			if ps.System, err = gnss.ParseSatelliteSystem(hr.Value[:1]); err != nil {
+				return err
+			}
+			if strings.TrimSpace(hr.Value[2:5]) != "" {
+				if ps.ObservationType, err = gnss.ParseObservationCode(hr.Value[2:5]); err != nil {
+					return err
+				}
+				if ps.Correction, err = strconv.ParseFloat(strings.TrimSpace(hr.Value[6:14]), 64); err != nil {
+					return err
+				}
+				numSatellites, err := parseOptionalInt(hr.Value[16:18])
+				if err != nil {
+					return err
+				}
+				satellites, err := parseListRecord(s, hr, 18, 4, 10, numSatellites, numSatellites != 0)
+				if err != nil {
+					return err
+				}
+				if ps.Satellites, err = parseSatelliteIDs(satellites); err != nil {
+					return err
+				}
+			}
+			h.PhaseShifts = append(h.PhaseShifts, ps)
+			return nil
 		},
-		"GLONASS SLOT / FRQ #": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+		"GLONASS SLOT / FRQ #": func(s *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			numSatellites, err := parseOptionalInt(hr.Value[:3])
+			if err != nil {
+				return err
+			}
+			// Each "R01  1" slot is parsed as one field
+			slots, err := parseListRecord(s, hr, 3, 7, 8, numSatellites, numSatellites != 0)
+			if err != nil {
+				return err
+			}
+			for _, slot := range slots {
+				if len(slot) < 4 {
+					return HeaderRecordPatternError
+				}
+				satellite, err := gnss.ParseSatelliteID(slot[:3])
+				if err != nil {
+					return err
+				}
+				frequency, err := strconv.Atoi(strings.TrimSpace(slot[3:]))
+				if err != nil {
+					return err
+				}
+				h.GLONASSSlots[satellite] = frequency
+			}
+			return nil
 		},
 		"GLONASS COD/PHS/BIS": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
 			if strings.TrimSpace(hr.Value) == "" {
@@ -167,7 +273,10 @@ var (
 			}
 
 			for _, cpbMatch := range match {
-				code := strings.TrimSpace(cpbMatch[2])
+				code, err := gnss.ParseObservationCode(cpbMatch[2])
+				if err != nil {
+					return err
+				}
 				correction, err := strconv.ParseFloat(strings.TrimSpace(cpbMatch[3]), 64)
 				if err != nil {
 					return err
@@ -178,77 +287,155 @@ var (
 			return nil
 		},
 		"LEAP SECONDS": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			h.LeapSeconds, err = ParseLeapSeconds(hr.Value)
+			return err
 		},
 		"# OF SATELLITES": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+			h.NumSatellites, err = strconv.Atoi(strings.TrimSpace(hr.Value[:6]))
+			return err
 		},
-		"PRN / # OF OBS": func(_ *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
-			return err // TODO:
+		"PRN / # OF OBS": func(s *scanner.Scanner, h *ObservationHeader, hr header.HeaderRecord) (err error) {
+			satellite, err := gnss.ParseSatelliteID(hr.Value[3:6])
+			if err != nil {
+				return err
+			}
+			// Counts follow for each observation type of the satellite system
+			numTypes := len(h.ObservationTypes[satellite.System])
+			fields, err := parseListRecord(s, hr, 6, 6, 9, numTypes, numTypes != 0)
+			if err != nil {
+				return err
+			}
+			counts := make([]int, len(fields))
+			for i, field := range fields {
+				if counts[i], err = strconv.Atoi(field); err != nil {
+					return err
+				}
+			}
+			h.ObservationCounts[satellite] = counts
+			return nil
 		},
 	}
 )
 
-type Time struct { // TODO: time.Time
-	Year   int64
-	Month  int64
-	Day    int64
-	Hour   int64
-	Minute int64
-	Second float64
-	System string
+// parseXYZ parses a record value of three F14.4 coordinates
+func parseXYZ(value string) (xyz XYZ, err error) {
+	if xyz.X, err = strconv.ParseFloat(strings.TrimSpace(value[:14]), 64); err != nil {
+		return xyz, err
+	}
+	if xyz.Y, err = strconv.ParseFloat(strings.TrimSpace(value[14:28]), 64); err != nil {
+		return xyz, err
+	}
+	xyz.Z, err = strconv.ParseFloat(strings.TrimSpace(value[28:42]), 64)
+	return xyz, err
 }
 
-func ParseTimeRecord(line string) (t Time, err error) {
-	t.Year, err = strconv.ParseInt(strings.TrimSpace(line[:6]), 10, 64)
-	if err != nil {
-		return t, err
+func parseAppliedCorrection(value string) (c AppliedCorrection, err error) {
+	c.System, err = gnss.ParseSatelliteSystem(value[:1])
+	c.Program = strings.TrimSpace(value[2:19])
+	c.Source = strings.TrimSpace(value[20:60])
+	return c, err
+}
+
+func parseObservationCodes(fields []string) (codes []gnss.ObservationCode, err error) {
+	for _, field := range fields {
+		code, err := gnss.ParseObservationCode(field)
+		if err != nil {
+			return codes, err
+		}
+		codes = append(codes, code)
 	}
-	t.Month, err = strconv.ParseInt(strings.TrimSpace(line[6:12]), 10, 64)
-	if err != nil {
-		return t, err
+	return codes, nil
+}
+
+func parseSatelliteIDs(fields []string) (satellites []gnss.SatelliteID, err error) {
+	for _, field := range fields {
+		satellite, err := gnss.ParseSatelliteID(field)
+		if err != nil {
+			return satellites, err
+		}
+		satellites = append(satellites, satellite)
 	}
-	t.Day, err = strconv.ParseInt(strings.TrimSpace(line[12:18]), 10, 64)
-	if err != nil {
-		return t, err
+	return satellites, nil
+}
+
+// parseListRecord parses the list of fields of a record, starting at the
+// offset column and separated by the width of each field, with up to
+// perLine fields on each line. If the total is known, the list continues on
+// the following records with the same label until there are total fields,
+// and a blank field is an error. Otherwise all non-blank fields of the first
+// line are returned.
+func parseListRecord(s *scanner.Scanner, hr header.HeaderRecord, offset, width, perLine, total int, totalKnown bool) (fields []string, err error) {
+	if !totalKnown {
+		total = perLine
 	}
-	t.Hour, err = strconv.ParseInt(strings.TrimSpace(line[18:24]), 10, 64)
-	if err != nil {
-		return t, err
+	for {
+		for i := 0; i < perLine && len(fields) < total; i++ {
+			start := offset + i*width
+			field := strings.TrimSpace(hr.Value[start : start+width])
+			if field == "" {
+				if !totalKnown {
+					return fields, nil
+				}
+				return fields, HeaderRecordPatternError
+			}
+			fields = append(fields, field)
+		}
+		if len(fields) >= total {
+			return fields, nil
+		}
+
+		key := hr.Key
+		if hr, err = header.ParseHeaderRecord(s); err != nil {
+			return fields, err
+		}
+		if hr.Key != key || strings.TrimSpace(hr.Value[:offset]) != "" {
+			return fields, HeaderRecordPatternError
+		}
 	}
-	t.Minute, err = strconv.ParseInt(strings.TrimSpace(line[24:30]), 10, 64)
-	if err != nil {
-		return t, err
+}
+
+// ParseTimeRecord parses the time of a TIME OF FIRST OBS or TIME OF LAST OBS
+// record, whose time system is empty if the field is blank.
+func ParseTimeRecord(line string) (t gnss.Time, err error) {
+	var fields [5]int
+	for i := range fields {
+		if fields[i], err = strconv.Atoi(strings.TrimSpace(line[6*i : 6*i+6])); err != nil {
+			return t, err
+		}
 	}
-	t.Second, err = strconv.ParseFloat(strings.TrimSpace(line[30:43]), 64)
+	seconds, err := strconv.ParseFloat(strings.TrimSpace(line[30:43]), 64)
 	if err != nil {
 		return t, err
 	}
-	t.System = strings.TrimSpace(line[48:51])
-	return t, nil
+
+	var system gnss.TimeSystem
+	if field := strings.TrimSpace(line[48:51]); field != "" {
+		if system, err = gnss.ParseTimeSystem(field); err != nil {
+			return t, err
+		}
+	}
+	return gnss.Date(system, fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], seconds), nil
 }
 
+// ParseObservationHeaderRecord parses the next header record into obsHeader.
 func ParseObservationHeaderRecord(scanner *scanner.Scanner, obsHeader *ObservationHeader) (hr header.HeaderRecord, err error) {
 	hr, err = header.ParseHeaderRecord(scanner)
 	if err != nil {
 		return hr, err
 	}
 
-	if parser, ok := header.HeaderRecordParsers[hr.Key]; ok {
-		err = parser(scanner, &obsHeader.Header, hr)
-		if err == nil {
-			return hr, nil
-		}
+	if err = parseObservationHeaderRecord(scanner, obsHeader, hr); err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
 	}
+	return hr, nil
+}
 
+func parseObservationHeaderRecord(scanner *scanner.Scanner, obsHeader *ObservationHeader, hr header.HeaderRecord) error {
+	if parser, ok := header.HeaderRecordParsers[hr.Key]; ok {
+		return parser(scanner, &obsHeader.Header, hr)
+	}
 	if parser, ok := ObservationHeaderRecordParsers[hr.Key]; ok {
-		err = parser(scanner, obsHeader, hr)
-		if err == nil {
-			return hr, nil
-		}
-	} else {
-		err = errors.New(fmt.Sprintf("invalid header label \"%s\"", hr.Key))
+		return parser(scanner, obsHeader, hr)
 	}
-
-	return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	return errors.New(fmt.Sprintf("invalid header label \"%s\"", hr.Key))
 }
@@ -0,0 +1,95 @@
+package rinex3
+
+import (
+	"math"
+	"strings"
+
+	"github.com/go-gnss/rinex/gnss"
+)
+
+// LLI is the loss of lock indicator of an observation, a bitmask which is
+// 0 when the field is blank
+type LLI int
+
+const (
+	LLILossOfLock         LLI = 1 << iota // Lost lock between the previous and current observation
+	LLIHalfCycleAmbiguity                 // Half cycle ambiguity or slip possible
+	LLIBOCTracking                        // BOC tracking of an MBOC signal in RINEX 3, anti-spoofing in RINEX 2
+)
+
+// LLIAntiSpoofing is the RINEX 2 meaning of the third bit of the LLI
+const LLIAntiSpoofing = LLIBOCTracking
+
+func (l LLI) has(flag LLI) bool {
+	return l&flag != 0
+}
+
+// LossOfLock returns true if lock was lost between the previous and current
+// observation, so a cycle slip is possible.
+func (l LLI) LossOfLock() bool {
+	return l.has(LLILossOfLock)
+}
+
+// HalfCycleAmbiguity returns true if a half cycle ambiguity or slip is
+// possible.
+func (l LLI) HalfCycleAmbiguity() bool {
+	return l.has(LLIHalfCycleAmbiguity)
+}
+
+// BOCTracking returns true if a Galileo MBOC modulated signal was tracked as
+// BOC, which is only meaningful from RINEX 3.
+func (l LLI) BOCTracking() bool {
+	return l.has(LLIBOCTracking)
+}
+
+// AntiSpoofing returns true if the observation was made while anti-spoofing
+// was on, which is only meaningful in RINEX 2.
+func (l LLI) AntiSpoofing() bool {
+	return l.has(LLIAntiSpoofing)
+}
+
+// SSI is the signal strength indicator of an observation, ranging from 1
+// (minimum possible signal strength) through 5 (the threshold for a good
+// signal to noise ratio) to 9 (maximum possible signal strength). 0 means
+// unknown, as is a blank field.
+type SSI int
+
+// NewSSI returns the SSI for a carrier to noise density in dBHz.
+func NewSSI(dbhz float64) SSI {
+	switch {
+	case dbhz < 12:
+		return 1
+	case dbhz >= 54:
+		return 9
+	default:
+		return SSI(int(dbhz-12)/6 + 2)
+	}
+}
+
+// DBHz returns the range of carrier to noise density in dBHz which the SSI
+// represents, from min (inclusive) to max (exclusive). ok is false for an
+// unknown or blank SSI.
+func (s SSI) DBHz() (min, max float64, ok bool) {
+	switch {
+	case s < 1 || s > 9:
+		return 0, 0, false
+	case s == 1:
+		return 0, 12, true
+	case s == 9:
+		return 54, math.Inf(1), true
+	default:
+		min = 12 + 6*float64(s-2)
+		return min, min + 6, true
+	}
+}
+
+// SignalStrengthDBHz returns the range of carrier to noise density of an
+// observation in dBHz. A signal strength (S) observation gives its own value
+// when the SIGNAL STRENGTH UNIT of the header is DBHZ, and otherwise the
+// range is given by the SSI of the observation.
+func (h ObservationHeader) SignalStrengthDBHz(obs Observation) (min, max float64, ok bool) {
+	if obs.Valid && obs.Code.Type == gnss.SignalStrength && strings.EqualFold(h.SignalStrength, "DBHZ") {
+		return obs.Value, obs.Value, true
+	}
+	return obs.SignalStrength.DBHz()
+}
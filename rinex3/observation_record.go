@@ -0,0 +1,92 @@
+package rinex3
+
+import (
+	"strings"
+
+	"github.com/go-gnss/rinex/gnss"
+)
+
+// Priority gives the order in which observation attributes (tracking modes)
+// are preferred when selecting the best available observation of a
+// frequency band, as a string of attributes for each band of each satellite
+// system. For example, {gnss.GPS: {1: "CW"}} prefers C1C over C1W.
+//
+// Observations with attributes which aren't listed, including RINEX 2 codes
+// which have no attribute, are ranked after those which are in the order
+// they appear in the record.
+type Priority map[gnss.SatelliteSystem]map[int]string
+
+// DefaultPriority is the Priority used by the Pseudorange, Phase, Doppler and
+// SNR methods of ObservationRecord, preferring the most widely tracked
+// signal of each band, such as C1C and C2W for GPS.
+var DefaultPriority = Priority{
+	gnss.GPS:     {1: "CSLXPWYMN", 2: "WPYCSLXDMN", 5: "QXI"},
+	gnss.GLONASS: {1: "CP", 2: "CP", 3: "QXI", 4: "BXA", 6: "BXA"},
+	gnss.Galileo: {1: "CXBAZ", 5: "QXI", 6: "CXBAZ", 7: "QXI", 8: "QXI"},
+	gnss.BeiDou:  {1: "IQXPDSLZ", 2: "IQX", 5: "PXD", 6: "IQXPDZ", 7: "IQXPDZ", 8: "PXD"},
+	gnss.QZSS:    {1: "CSLXZBE", 2: "LXS", 5: "QXIPDZ", 6: "LXSEZ"},
+	gnss.IRNSS:   {5: "AXBC", 9: "AXBC"},
+	gnss.SBAS:    {1: "C", 5: "QXI"},
+}
+
+// Get returns the observation of the record with the given code, such as
+// L1C. ok is false if the record has no observation of the code, or the
+// observation is blank.
+func (r ObservationRecord) Get(code string) (obs Observation, ok bool) {
+	for _, obs := range r.Observations {
+		if obs.Code.String() == code {
+			return obs, obs.Valid
+		}
+	}
+	return obs, false
+}
+
+// Pseudorange returns the best available pseudorange of a frequency band,
+// including RINEX 2 P-code pseudoranges, according to DefaultPriority.
+func (r ObservationRecord) Pseudorange(band int) (Observation, bool) {
+	return r.Best(gnss.Pseudorange, band, DefaultPriority)
+}
+
+// Phase returns the best available carrier phase of a frequency band
+// according to DefaultPriority.
+func (r ObservationRecord) Phase(band int) (Observation, bool) {
+	return r.Best(gnss.Phase, band, DefaultPriority)
+}
+
+// Doppler returns the best available Doppler of a frequency band according to
+// DefaultPriority.
+func (r ObservationRecord) Doppler(band int) (Observation, bool) {
+	return r.Best(gnss.Doppler, band, DefaultPriority)
+}
+
+// SNR returns the best available signal strength observation (the S
+// observation type, not the SSI of an observation) of a frequency band
+// according to DefaultPriority.
+func (r ObservationRecord) SNR(band int) (Observation, bool) {
+	return r.Best(gnss.SignalStrength, band, DefaultPriority)
+}
+
+// Best returns the non-blank observation of the given type and frequency band
+// whose attribute comes first in the priority of the record's satellite
+// system. ok is false if the record has no such observation.
+func (r ObservationRecord) Best(t gnss.ObservationType, band int, priority Priority) (best Observation, ok bool) {
+	attributes := priority[r.Satellite.System][band]
+	bestRank := 0
+	for _, obs := range r.Observations {
+		code := obs.Code
+		if code.Band != band || !obs.Valid || (code.Type != t && (t != gnss.Pseudorange || code.Type != gnss.PCode)) {
+			continue
+		}
+
+		rank := len(attributes)
+		if code.Attribute != 0 {
+			if i := strings.IndexByte(attributes, code.Attribute); i >= 0 {
+				rank = i
+			}
+		}
+		if !ok || rank < bestRank {
+			best, bestRank, ok = obs, rank, true
+		}
+	}
+	return best, ok
+}
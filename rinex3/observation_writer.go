@@ -0,0 +1,383 @@
+package rinex3
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/header"
+)
+
+var (
+	satelliteSystemOrder string = "GRECJIS"
+
+	glonassCodePhaseBiasCodes []gnss.ObservationCode = []gnss.ObservationCode{
+		{Type: gnss.Pseudorange, Band: 1, Attribute: 'C'},
+		{Type: gnss.Pseudorange, Band: 1, Attribute: 'P'},
+		{Type: gnss.Pseudorange, Band: 2, Attribute: 'C'},
+		{Type: gnss.Pseudorange, Band: 2, Attribute: 'P'},
+	}
+)
+
+// ObservationWriter writes a RINEX 3 observation file to an io.Writer one
+// epoch at a time, so that arbitrarily large files can be streamed.
+type ObservationWriter struct {
+	w      io.Writer
+	Header ObservationHeader
+}
+
+// NewObservationWriter writes the header records of h to w, and returns an
+// ObservationWriter for writing the epochs which follow.
+func NewObservationWriter(w io.Writer, h ObservationHeader) (*ObservationWriter, error) {
+	writer := &ObservationWriter{w, h}
+	_, err := io.WriteString(w, strings.Join(FormatObservationHeader(h), ""))
+	return writer, err
+}
+
+// WriteEpoch writes an EpochRecord and its ObservationRecords, in the order
+// of the SYS / # / OBS TYPES of the header. The HeaderRecords of special
+// events are applied to the Header, so that the epochs which follow are
+// written with any new observation types.
+func (w *ObservationWriter) WriteEpoch(epoch EpochRecord) error {
+	record, err := FormatEpochRecord(epoch, w.Header.ObservationTypes)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w.w, record); err != nil {
+		return err
+	}
+	if len(epoch.HeaderRecords) > 0 {
+		h, err := w.Header.WithRecords(epoch.HeaderRecords)
+		if err != nil {
+			return err
+		}
+		w.Header = h
+	}
+	return nil
+}
+
+// FormatObservationHeader formats the records of an ObservationHeader as
+// lines in the order given in the RINEX 3 specification, with empty optional
+// records omitted.
+func FormatObservationHeader(h ObservationHeader) (lines []string) {
+	lines = FormatHeader(h.Header, "OBSERVATION DATA")
+
+	lines = append(lines, header.FormatHeaderRecord(h.Marker.Name, "MARKER NAME"))
+	if h.Marker.Number != "" {
+		lines = append(lines, header.FormatHeaderRecord(h.Marker.Number, "MARKER NUMBER"))
+	}
+	if h.Marker.Type != "" {
+		lines = append(lines, header.FormatHeaderRecord(h.Marker.Type, "MARKER TYPE"))
+	}
+	lines = append(lines,
+		header.FormatHeaderRecord(fmt.Sprintf("%-20.20s%-40.40s", h.Observer, h.Agency), "OBSERVER / AGENCY"),
+		header.FormatHeaderRecord(fmt.Sprintf("%-20.20s%-20.20s%-20.20s", h.Receiver.Number, h.Receiver.Type, h.Receiver.Version), "REC # / TYPE / VERS"),
+		header.FormatHeaderRecord(fmt.Sprintf("%-20.20s%-20.20s", h.Antenna.Number, h.Antenna.Type), "ANT # / TYPE"),
+		header.FormatHeaderRecord(fmt.Sprintf("%14.4f%14.4f%14.4f", h.Marker.ApproxPosition.X, h.Marker.ApproxPosition.Y, h.Marker.ApproxPosition.Z), "APPROX POSITION XYZ"),
+		header.FormatHeaderRecord(fmt.Sprintf("%14.4f%14.4f%14.4f", h.Antenna.Height, h.Antenna.East, h.Antenna.North), "ANTENNA: DELTA H/E/N"),
+	)
+	if h.Antenna.DeltaXYZ != (XYZ{}) {
+		lines = append(lines, header.FormatHeaderRecord(formatXYZ(h.Antenna.DeltaXYZ), "ANTENNA: DELTA X/Y/Z"))
+	}
+	for _, pc := range h.Antenna.PhaseCenters {
+		value := fmt.Sprintf("%-1.1s %-3.3s%9.4f%14.4f%14.4f", pc.System, pc.ObservationType, pc.Position.X, pc.Position.Y, pc.Position.Z)
+		lines = append(lines, header.FormatHeaderRecord(value, "ANTENNA: PHASECENTER"))
+	}
+	if h.Antenna.BoreSight != (XYZ{}) {
+		lines = append(lines, header.FormatHeaderRecord(formatXYZ(h.Antenna.BoreSight), "ANTENNA: B.SIGHT XYZ"))
+	}
+	if h.Antenna.ZeroDirectionAzimuth != 0 {
+		lines = append(lines, header.FormatHeaderRecord(fmt.Sprintf("%14.4f", h.Antenna.ZeroDirectionAzimuth), "ANTENNA: ZERODIR AZI"))
+	}
+	if h.Antenna.ZeroDirection != (XYZ{}) {
+		lines = append(lines, header.FormatHeaderRecord(formatXYZ(h.Antenna.ZeroDirection), "ANTENNA: ZERODIR XYZ"))
+	}
+	if h.CenterOfMass != (XYZ{}) {
+		lines = append(lines, header.FormatHeaderRecord(formatXYZ(h.CenterOfMass), "CENTER OF MASS: XYZ"))
+	}
+
+	systems := SortSatelliteSystems(h.ObservationTypes)
+	for _, system := range systems {
+		lines = append(lines, FormatObservationTypes(system, h.ObservationTypes[system])...)
+	}
+
+	if h.SignalStrength != "" {
+		lines = append(lines, header.FormatHeaderRecord(h.SignalStrength, "SIGNAL STRENGTH UNIT"))
+	}
+	if h.Interval != 0 {
+		lines = append(lines, header.FormatHeaderRecord(fmt.Sprintf("%10.3f", h.Interval), "INTERVAL"))
+	}
+	lines = append(lines, header.FormatHeaderRecord(FormatTimeRecord(h.TimeOfFirstObs), "TIME OF FIRST OBS"))
+	if !h.TimeOfLastObs.IsZero() {
+		lines = append(lines, header.FormatHeaderRecord(FormatTimeRecord(h.TimeOfLastObs), "TIME OF LAST OBS"))
+	}
+
+	if h.ClockOffsetApplied {
+		lines = append(lines, header.FormatHeaderRecord(fmt.Sprintf("%6d", 1), "RCV CLOCK OFFS APPL"))
+	}
+	for _, c := range h.DCBsApplied {
+		lines = append(lines, header.FormatHeaderRecord(formatAppliedCorrection(c), "SYS / DCBS APPLIED"))
+	}
+	for _, c := range h.PCVsApplied {
+		lines = append(lines, header.FormatHeaderRecord(formatAppliedCorrection(c), "SYS / PCVS APPLIED"))
+	}
+	for _, sf := range h.ScaleFactors {
+		value := fmt.Sprintf("%-1.1s %4d", sf.System, sf.Factor)
+		if len(sf.ObservationTypes) > 0 { // Otherwise all observation types
+			value += fmt.Sprintf("  %2d", len(sf.ObservationTypes))
+		}
+		lines = append(lines, formatListRecord(value, observationCodeStrings(sf.ObservationTypes), " %-3.3s", 12, "SYS / SCALE FACTOR")...)
+	}
+
+	if h.FormatVersion >= 3.01 {
+		if len(h.PhaseShifts) == 0 {
+			// Phase shifts are "not known" for each system
+			for _, system := range systems {
+				lines = append(lines, header.FormatHeaderRecord(system.String(), "SYS / PHASE SHIFT"))
+			}
+		}
+		for _, ps := range h.PhaseShifts {
+			value := ps.System.String()
+			if ps.ObservationType != (gnss.ObservationCode{}) {
+				value = fmt.Sprintf("%-1.1s %-3.3s %8.5f", ps.System, ps.ObservationType, ps.Correction)
+				if len(ps.Satellites) > 0 {
+					value += fmt.Sprintf("  %02d", len(ps.Satellites))
+				}
+			}
+			satellites := make([]string, len(ps.Satellites))
+			for i, satellite := range ps.Satellites {
+				satellites[i] = satellite.String()
+			}
+			lines = append(lines, formatListRecord(value, satellites, " %-3.3s", 10, "SYS / PHASE SHIFT")...)
+		}
+	}
+	if _, ok := h.ObservationTypes["R"]; (ok && h.FormatVersion >= 3.02) || len(h.GLONASSSlots) > 0 {
+		satellites := make([]gnss.SatelliteID, 0, len(h.GLONASSSlots))
+		for satellite := range h.GLONASSSlots {
+			satellites = append(satellites, satellite)
+		}
+		SortSatellites(satellites)
+		slots := make([]string, len(satellites))
+		for i, satellite := range satellites {
+			slots[i] = fmt.Sprintf("%-3.3s %2d", satellite, h.GLONASSSlots[satellite])
+		}
+		lines = append(lines, formatListRecord(fmt.Sprintf("%3d", len(slots)), slots, " %s", 8, "GLONASS SLOT / FRQ #")...)
+	}
+	if _, ok := h.ObservationTypes["R"]; (ok && h.FormatVersion >= 3.02) || len(h.GLONASSCodePhaseBias) > 0 {
+		var value string // Blank if the biases are unknown
+		if len(h.GLONASSCodePhaseBias) > 0 {
+			for _, code := range glonassCodePhaseBiasCodes {
+				value += fmt.Sprintf(" %3s %8.3f", code, h.GLONASSCodePhaseBias[code])
+			}
+		}
+		lines = append(lines, header.FormatHeaderRecord(value, "GLONASS COD/PHS/BIS"))
+	}
+
+	if h.LeapSeconds.Current != 0 {
+		lines = append(lines, header.FormatHeaderRecord(FormatLeapSeconds(h.LeapSeconds), "LEAP SECONDS"))
+	}
+	if h.NumSatellites != 0 {
+		lines = append(lines, header.FormatHeaderRecord(fmt.Sprintf("%6d", h.NumSatellites), "# OF SATELLITES"))
+	}
+	satellites := make([]gnss.SatelliteID, 0, len(h.ObservationCounts))
+	for satellite := range h.ObservationCounts {
+		satellites = append(satellites, satellite)
+	}
+	SortSatellites(satellites)
+	for _, satellite := range satellites {
+		counts := make([]string, len(h.ObservationCounts[satellite]))
+		for i, count := range h.ObservationCounts[satellite] {
+			counts[i] = fmt.Sprintf("%6d", count)
+		}
+		lines = append(lines, formatListRecord(fmt.Sprintf("   %-3.3s", satellite), counts, "%s", 9, "PRN / # OF OBS")...)
+	}
+
+	return append(lines, header.FormatHeaderRecord("", "END OF HEADER"))
+}
+
+// formatListRecord formats a record whose value is followed by a list of
+// fields in the given format, with perLine fields on each line. Continuation
+// lines are indented to the first field.
+func formatListRecord(value string, fields []string, format string, perLine int, key string) (lines []string) {
+	indent := len(value)
+	for i, field := range fields {
+		if i > 0 && i%perLine == 0 {
+			lines = append(lines, header.FormatHeaderRecord(value, key))
+			value = strings.Repeat(" ", indent)
+		}
+		value += fmt.Sprintf(format, field)
+	}
+	return append(lines, header.FormatHeaderRecord(value, key))
+}
+
+func formatXYZ(xyz XYZ) string {
+	return fmt.Sprintf("%14.4f%14.4f%14.4f", xyz.X, xyz.Y, xyz.Z)
+}
+
+func formatAppliedCorrection(c AppliedCorrection) string {
+	return fmt.Sprintf("%-1.1s %-17.17s %-40.40s", c.System, c.Program, c.Source)
+}
+
+// FormatHeader formats the RINEX VERSION / TYPE, PGM / RUN BY / DATE and
+// COMMENT records common to all RINEX 3 headers, using dataType to describe
+// the FileType (such as "OBSERVATION DATA").
+func FormatHeader(h header.Header, dataType string) []string {
+	// The name of the satellite system is written after it
+	system := h.SatelliteSystem
+	if name := gnss.SatelliteSystem(system).Name(); name != "" {
+		system = fmt.Sprintf("%s (%s)", system, name)
+	}
+
+	lines := []string{
+		header.FormatHeaderRecord(fmt.Sprintf("%9.2f%11s%-20.20s%-20.20s", h.FormatVersion, "", dataType, system), "RINEX VERSION / TYPE"),
+		header.FormatHeaderRecord(fmt.Sprintf("%-20.20s%-20.20s%-20.20s", h.Program, h.RunBy, h.CreationDate), "PGM / RUN BY / DATE"),
+	}
+	for _, comment := range h.Comments {
+		lines = append(lines, header.FormatHeaderRecord(comment.Comment, "COMMENT"))
+	}
+	return lines
+}
+
+// FormatObservationTypes formats the SYS / # / OBS TYPES record of a
+// satellite system, with continuation lines for more than 13 types.
+func FormatObservationTypes(system gnss.SatelliteSystem, types []gnss.ObservationCode) (lines []string) {
+	value := fmt.Sprintf("%-1.1s  %3d", system, len(types))
+	for i, obsType := range types {
+		if i > 0 && i%13 == 0 {
+			lines = append(lines, header.FormatHeaderRecord(value, "SYS / # / OBS TYPES"))
+			value = strings.Repeat(" ", 6)
+		}
+		value += fmt.Sprintf(" %-3.3s", obsType)
+	}
+	return append(lines, header.FormatHeaderRecord(value, "SYS / # / OBS TYPES"))
+}
+
+// FormatTimeRecord formats a Time as in the TIME OF FIRST OBS record.
+func FormatTimeRecord(t gnss.Time) string {
+	c := t.GoTime()
+	return fmt.Sprintf("%6d%6d%6d%6d%6d%13.7f%5s%-3.3s",
+		c.Year(), c.Month(), c.Day(), c.Hour(), c.Minute(), t.Seconds(), "", t.System())
+}
+
+// SortSatelliteSystems returns the satellite systems which are keys of a map
+// in the order G, R, E, C, J, I, S, followed by any others alphabetically.
+func SortSatelliteSystems(systems map[gnss.SatelliteSystem][]gnss.ObservationCode) []gnss.SatelliteSystem {
+	keys := make([]gnss.SatelliteSystem, 0, len(systems))
+	for system := range systems {
+		keys = append(keys, system)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return satelliteSystemLess(keys[i], keys[j])
+	})
+	return keys
+}
+
+// SortSatellites sorts satellites by satellite system in the same order as
+// SortSatelliteSystems, then by number.
+func SortSatellites(satellites []gnss.SatelliteID) {
+	sort.Slice(satellites, func(i, j int) bool {
+		a, b := satellites[i], satellites[j]
+		if a.System != b.System {
+			return satelliteSystemLess(a.System, b.System)
+		}
+		return a.Number < b.Number
+	})
+}
+
+func satelliteSystemLess(a, b gnss.SatelliteSystem) bool {
+	i, j := strings.Index(satelliteSystemOrder, string(a)), strings.Index(satelliteSystemOrder, string(b))
+	if i == -1 && j == -1 {
+		return a < b
+	}
+	return j == -1 || (i != -1 && i < j)
+}
+
+func observationCodeStrings(codes []gnss.ObservationCode) []string {
+	fields := make([]string, len(codes))
+	for i, code := range codes {
+		fields[i] = code.String()
+	}
+	return fields
+}
+
+// FormatEpochRecord formats an EpochRecord as its epoch line followed by an
+// observation line per ObservationRecord, each observation being written in
+// F14.3 followed by the LLI and signal strength. Special events are followed
+// by their HeaderRecords instead, with a zero Time being left blank.
+//
+// Missing observations, being those which aren't Valid or are beyond the end
+// of the Observations of a record, are written as blank fields, as are an
+// LLI and signal strength which the observation doesn't have. Trailing blanks are removed from each line.
+func FormatEpochRecord(epoch EpochRecord, observationTypes map[gnss.SatelliteSystem][]gnss.ObservationCode) (string, error) {
+	t := epoch.Time.GoTime()
+	seconds := epoch.Time.Seconds()
+	count := len(epoch.ObservationRecords)
+	if epoch.Flag.IsEvent() {
+		count = len(epoch.HeaderRecords)
+	}
+
+	var b strings.Builder
+	if epoch.Flag.IsEvent() && epoch.Time.IsZero() {
+		fmt.Fprintf(&b, ">%28s  %d%3d", "", epoch.Flag, count)
+	} else {
+		fmt.Fprintf(&b, "> %04d %02d %02d %02d %02d%11.7f  %d%3d",
+			t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), seconds, epoch.Flag, count)
+	}
+	if epoch.ClockOffset != 0 {
+		fmt.Fprintf(&b, "%6s%15.12f", "", epoch.ClockOffset)
+	}
+	b.WriteString("\n")
+
+	if epoch.Flag.IsEvent() {
+		b.WriteString(header.FormatHeaderRecords(epoch.HeaderRecords))
+		return b.String(), nil
+	}
+
+	for _, record := range epoch.ObservationRecords {
+		line := record.Satellite.String()
+		for i := range observationTypes[record.Satellite.System] {
+			if i >= len(record.Observations) {
+				break
+			}
+			field, err := FormatObservation(record.Observations[i])
+			if err != nil {
+				return b.String(), fmt.Errorf("invalid observation %s of %s: %v", record.Observations[i].Code, record.Satellite, err)
+			}
+			line += field
+		}
+		b.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	return b.String(), nil
+}
+
+// FormatObservation formats an Observation as F14.3,I1,I1, using blanks for
+// an invalid observation and for an LLI or signal strength which it doesn't
+// have. LLIs and signal strengths which it has must be from 0 to 9.
+func FormatObservation(obs Observation) (string, error) {
+	field := strings.Repeat(" ", 14)
+	if obs.Valid {
+		field = fmt.Sprintf("%14.3f", obs.Value)
+	}
+	lli, err := formatIndicator(int(obs.LLI), obs.HasLLI)
+	if err != nil {
+		return field, fmt.Errorf("LLI %v", err)
+	}
+	ssi, err := formatIndicator(int(obs.SignalStrength), obs.HasSSI)
+	if err != nil {
+		return field, fmt.Errorf("signal strength %v", err)
+	}
+	return field + lli + ssi, nil
+}
+
+func formatIndicator(indicator int, ok bool) (string, error) {
+	if !ok {
+		return " ", nil
+	}
+	if indicator < 0 || indicator > 9 {
+		return "", fmt.Errorf("%d is out of range", indicator)
+	}
+	return fmt.Sprintf("%1d", indicator), nil
+}
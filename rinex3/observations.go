@@ -8,60 +8,95 @@ import (
 	"strings"
 	"time"
 
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/header"
 	"github.com/go-gnss/rinex/scanner"
 )
 
+// EpochFlag is the flag of an epoch record, with flags 2 to 5 being special
+// events which are followed by header records rather than observations
+type EpochFlag int
+
+const (
+	EpochOK                EpochFlag = iota
+	EpochPowerFailure                // Power failure between the previous and current epoch
+	EpochStartMoving                 // Start moving antenna, with kinematic data following
+	EpochNewOccupation               // New site occupation, with at least MARKER NAME following
+	EpochHeaderInformation           // Header records follow
+	EpochExternalEvent               // External event, such as a camera exposure
+	EpochCycleSlips                  // Cycle slip records follow, in the format of observations
+)
+
+// IsEvent returns true for the special event flags 2 to 5.
+func (f EpochFlag) IsEvent() bool {
+	return f >= EpochStartMoving && f <= EpochExternalEvent
+}
+
+// EpochRecord is an epoch of observations, or a special event which has
+// HeaderRecords instead. The Time of an event is zero if it was left blank,
+// and NumSatellites is the number of its HeaderRecords.
 type EpochRecord struct {
-	Time               time.Time
-	Flag               int
+	Time               gnss.Time
+	Flag               EpochFlag
 	NumSatellites      int
 	ClockOffset        float64
 	ObservationRecords []ObservationRecord
+	HeaderRecords      []header.HeaderRecord
 }
 
 type ObservationRecord struct {
-	Constellation   string
-	SatelliteNumber int
-	Observations    []Observation
+	Satellite    gnss.SatelliteID
+	Observations []Observation
 }
 
+// Observation is a single observation of a satellite, with the Code it was
+// listed under in the header. Valid is false for a blank observation, being
+// one which wasn't made, and HasLLI and HasSSI are false for a blank LLI or
+// signal strength, as distinct from a value of 0.
 type Observation struct {
+	Code           gnss.ObservationCode
 	Value          float64
-	LLI            int
-	SignalStrength int
+	Valid          bool
+	LLI            LLI
+	HasLLI         bool
+	SignalStrength SSI
+	HasSSI         bool
 }
 
-func ParseEpochRecord(s *scanner.Scanner, observationTypes map[string][]string) (epoch EpochRecord, err error) {
+// MissingObservation returns the blank observation of a code.
+func MissingObservation(code gnss.ObservationCode) Observation {
+	return Observation{Code: code}
+}
+
+// ParseEpochRecord parses an epoch and its observation records, with the
+// epoch being in the time system of the file.
+func ParseEpochRecord(s *scanner.Scanner, observationTypes map[gnss.SatelliteSystem][]gnss.ObservationCode, system gnss.TimeSystem) (epoch EpochRecord, err error) {
 	line, err := s.ReadLine()
 	if err != nil {
 		return epoch, err
 	}
 
-	if string(line[0]) != ">" {
+	if len(line) < 35 || string(line[0]) != ">" {
 		return epoch, fmt.Errorf("invalid epoch record at line %d", s.Line)
 	}
 
-	t, err := time.Parse("2006 01 02 15 04", line[2:18])
-	if err != nil {
-		return epoch, err
-	}
-	seconds, err := strconv.ParseInt(strings.TrimSpace(line[19:21]), 10, 8)
-	if err != nil {
-		return epoch, err
-	}
-	milliseconds, err := strconv.ParseInt(strings.TrimSpace(line[22:29]), 10, 64)
+	flag, err := strconv.ParseInt(line[31:32], 10, 8)
 	if err != nil {
 		return epoch, err
 	}
-	t = t.Add(time.Duration(seconds) * time.Second)
-	t = t.Add(time.Duration(milliseconds) * time.Millisecond)
-	epoch.Time = t
+	epoch.Flag = EpochFlag(flag)
 
-	flag, err := strconv.ParseInt(line[31:32], 10, 8)
-	if err != nil {
-		return epoch, err
+	if strings.TrimSpace(line[2:29]) != "" || !epoch.Flag.IsEvent() {
+		t, err := time.Parse("2006 01 02 15 04", line[2:18])
+		if err != nil {
+			return epoch, err
+		}
+		seconds, err := strconv.ParseFloat(strings.TrimSpace(line[18:29]), 64)
+		if err != nil {
+			return epoch, err
+		}
+		epoch.Time = gnss.Date(system, t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), seconds)
 	}
-	epoch.Flag = int(flag)
 
 	numSats, err := strconv.ParseInt(strings.TrimSpace(line[32:35]), 10, 8)
 	if err != nil {
@@ -69,21 +104,24 @@ func ParseEpochRecord(s *scanner.Scanner, observationTypes map[string][]string)
 	}
 	epoch.NumSatellites = int(numSats)
 
-	offset := strings.TrimSpace(line[35 : len(line)-1])
-	if offset != "" {
+	if offset := strings.TrimSpace(line[35:]); offset != "" {
 		epoch.ClockOffset, err = strconv.ParseFloat(offset, 64)
 		if err != nil {
 			return epoch, err
 		}
 	}
 
+	if epoch.Flag.IsEvent() {
+		epoch.HeaderRecords, err = ParseEventRecords(s, epoch.NumSatellites)
+		return epoch, err
+	}
+
 	// Parse each ObservationRecord within EpochRecord
 	for i := 0; i < int(numSats); i++ {
 		line, err = s.ReadLine()
 		if err != nil {
 			return epoch, err
 		}
-		line = line[:len(line)-1] + "  " // Cheating because for some reason the fixture data doesn't have space for LLI or Signal strength for the last record (not optional in spec...)
 
 		record, err := ParseObservationRecord(line, observationTypes)
 		if err != nil {
@@ -96,37 +134,52 @@ func ParseEpochRecord(s *scanner.Scanner, observationTypes map[string][]string)
 	return epoch, err
 }
 
-func ParseObservationRecord(line string, observationTypes map[string][]string) (record ObservationRecord, err error) {
-	sat, err := strconv.ParseInt(strings.TrimSpace(line[1:3]), 10, 64)
+// ParseEventRecords parses the header records following a special event.
+func ParseEventRecords(s *scanner.Scanner, n int) (records []header.HeaderRecord, err error) {
+	for i := 0; i < n; i++ {
+		hr, err := header.ParseHeaderRecord(s)
+		if err != nil {
+			return records, fmt.Errorf("invalid event record at line %d: %v", s.Line, err)
+		}
+		records = append(records, hr)
+	}
+	return records, nil
+}
+
+func ParseObservationRecord(line string, observationTypes map[gnss.SatelliteSystem][]gnss.ObservationCode) (record ObservationRecord, err error) {
+	record.Satellite, err = gnss.ParseSatelliteID(line[:3])
 	if err != nil {
 		return record, err
 	}
 
-	record.Constellation = line[0:1]
-	record.SatelliteNumber = int(sat)
-
-	for i := 0; i < len(observationTypes[record.Constellation]); i++ {
-		// account for line ending early if not all signals present for satellite
-		if len(line) < (19 + (16 * i)) {
-			break
-		}
-
+	// Lines often end early when the last observations are blank, so the
+	// line is padded so that every code of the system has an observation
+	codes := observationTypes[record.Satellite.System]
+	if length := 3 + 16*len(codes); len(line) < length {
+		line += strings.Repeat(" ", length-len(line))
+	}
+	record.Observations = make([]Observation, 0, len(codes))
+	for i, code := range codes {
 		observation, err := ParseObservation(line[3+(16*i) : 19+(16*i)])
 		if err != nil {
 			return record, err
 		}
+		observation.Code = code
 		record.Observations = append(record.Observations, observation)
 	}
 
 	return record, nil
 }
 
+// ParseObservation parses an F14.3,I1,I1 observation field, for which blanks
+// give an invalid observation and no LLI or signal strength.
 func ParseObservation(data string) (obs Observation, err error) {
 	if data[:14] != "              " {
 		obs.Value, err = strconv.ParseFloat(strings.TrimSpace(data[:14]), 64)
 		if err != nil {
 			return obs, err
 		}
+		obs.Valid = true
 	}
 
 	if data[14:15] != " " {
@@ -134,15 +187,15 @@ func ParseObservation(data string) (obs Observation, err error) {
 		if err != nil {
 			return obs, err
 		}
-		obs.LLI = int(lli)
+		obs.LLI, obs.HasLLI = LLI(lli), true
 	}
 
-	if data[14:15] != " " {
+	if data[15:16] != " " {
 		strength, err := strconv.ParseInt(data[15:16], 10, 8)
 		if err != nil {
 			return obs, err
 		}
-		obs.SignalStrength = int(strength)
+		obs.SignalStrength, obs.HasSSI = SSI(strength), true
 	}
 
 	return obs, nil
@@ -0,0 +1,62 @@
+package rinex3
+
+import (
+	"github.com/go-gnss/rinex/gnss"
+)
+
+// Occupation is a segment of the epochs of an observation file at a single
+// marker, or while moving for a Kinematic segment, as separated by start
+// moving and new occupation events
+type Occupation struct {
+	Marker    string
+	Kinematic bool
+	Start     gnss.Time
+	End       gnss.Time
+	Epochs    int // Number of observation epochs in the segment
+}
+
+// UpdateOccupations returns the occupations updated with the next epoch of
+// a file, with the marker name of the header in effect at that epoch. The
+// first epoch starts the first occupation, and start moving and new
+// occupation events start a new one, from the time of the event or, if it
+// has none, from the next observation epoch. Other events which change the
+// marker name also start a new occupation, so that the epochs before them
+// stay at the previous marker.
+func UpdateOccupations(occupations []Occupation, epoch EpochRecord, marker string) []Occupation {
+	switch {
+	case epoch.Flag == EpochStartMoving || epoch.Flag == EpochNewOccupation:
+		return append(occupations, Occupation{
+			Marker:    marker,
+			Kinematic: epoch.Flag == EpochStartMoving,
+			Start:     epoch.Time,
+		})
+	case epoch.Flag.IsEvent():
+		// Header information or external events, where header information may
+		// change the marker
+		if len(occupations) == 0 {
+			return occupations
+		}
+		current := &occupations[len(occupations)-1]
+		if current.Marker == marker {
+			return occupations
+		}
+		if current.Epochs == 0 {
+			current.Marker = marker
+			return occupations
+		}
+		return append(occupations, Occupation{Marker: marker, Kinematic: current.Kinematic, Start: epoch.Time})
+	case epoch.Flag == EpochCycleSlips:
+		return occupations
+	}
+
+	if len(occupations) == 0 {
+		occupations = append(occupations, Occupation{Marker: marker})
+	}
+	current := &occupations[len(occupations)-1]
+	if current.Start.IsZero() {
+		current.Start = epoch.Time
+	}
+	current.End = epoch.Time
+	current.Epochs++
+	return occupations
+}
@@ -0,0 +1,61 @@
+package rinex3
+
+import (
+	"time"
+
+	"github.com/go-gnss/rinex/gnss"
+	"github.com/go-gnss/rinex/gnsstime"
+)
+
+// Correction returns the time system correction of the record.
+func (c TimeSystemCorrection) Correction() (gnsstime.Correction, error) {
+	return gnsstime.NewCorrection(c.Type, c.A0, c.A1, c.ReferenceTime, c.ReferenceWeek)
+}
+
+// Apply returns a copy of a leap second table updated with the record. The
+// Future leap seconds apply from the end of the FutureDay of FutureWeek if
+// they are given, where days are numbered from 1 (Sunday) to 7 for GPS and
+// from 0 to 6 for BDS, and the Current leap seconds apply from the
+// time of the file (its TIME OF FIRST OBS, within a few seconds) if the table
+// doesn't already agree. BDS leap seconds are converted to GPS - UTC.
+func (ls LeapSeconds) Apply(table gnsstime.LeapSecondTable, at gnss.Time) gnsstime.LeapSecondTable {
+	epoch, day, offset := gnss.GPSEpoch, ls.FutureDay, 0
+	if ls.System == "BDS" {
+		epoch, day, offset = gnss.BeiDouEpoch, ls.FutureDay+1, int(gnsstime.BeiDouOffset/time.Second)
+	}
+
+	if ls.FutureWeek != 0 || ls.FutureDay != 0 {
+		table = table.With(gnsstime.LeapSecond{
+			Time:   epoch.AddDate(0, 0, 7*ls.FutureWeek+day),
+			Offset: ls.Future + offset,
+		})
+	}
+	if ls.Current != 0 && !at.IsZero() && table.AtUTC(at.GoTime()) != ls.Current+offset {
+		table = table.With(gnsstime.LeapSecond{Time: at.GoTime(), Offset: ls.Current + offset})
+	}
+	return table
+}
+
+// TimeConverter returns a converter between time systems which uses the
+// LEAP SECONDS record of the header, if there is one.
+func (h ObservationHeader) TimeConverter() *gnsstime.Converter {
+	converter := gnsstime.NewConverter()
+	converter.LeapSeconds = h.LeapSeconds.Apply(converter.LeapSeconds, h.TimeOfFirstObs)
+	return converter
+}
+
+// TimeConverter returns a converter between time systems which uses the
+// TIME SYSTEM CORR and LEAP SECONDS records of the header. As navigation
+// headers have no time, the Current leap seconds apply from the given time,
+// such as the start of the file. Unsupported TIME SYSTEM CORR types such as
+// SBUT are skipped.
+func (h NavigationHeader) TimeConverter(at gnss.Time) *gnsstime.Converter {
+	converter := gnsstime.NewConverter()
+	converter.LeapSeconds = h.LeapSeconds.Apply(converter.LeapSeconds, at)
+	for _, c := range h.TimeSystemCorrections {
+		if correction, err := c.Correction(); err == nil {
+			converter.Corrections = append(converter.Corrections, correction)
+		}
+	}
+	return converter
+}
@@ -0,0 +1,229 @@
+package rinex4
+
+import (
+	"fmt"
+
+	"github.com/go-gnss/rinex/rinex3"
+)
+
+// CNAVEphemeris is a GPS or QZSS CNAV (L2C/L5) ephemeris
+type CNAVEphemeris struct {
+	rinex3.EphemerisHeader
+	ClockBias      float64
+	ClockDrift     float64
+	ClockDriftRate float64
+	ADot           float64
+	rinex3.KeplerianOrbit
+	DeltaNDot        float64
+	URAINED0         float64
+	URAINED1         float64
+	URAIED           float64
+	Health           float64
+	TGD              float64
+	URAINED2         float64
+	ISCL1CA          float64
+	ISCL2C           float64
+	ISCL5I5          float64
+	ISCL5Q5          float64
+	TransmissionTime float64
+	WeekOfPrediction float64
+	Flags            float64
+}
+
+// The Toe slot of the Keplerian orbit holds Top (time of prediction) for
+// CNAV messages, with Toe being equal to the Time of Clock
+func (e *CNAVEphemeris) Fields() []*float64 {
+	o := &e.KeplerianOrbit
+	return []*float64{
+		&e.ClockBias, &e.ClockDrift, &e.ClockDriftRate,
+		&e.ADot, &o.Crs, &o.DeltaN, &o.M0,
+		&o.Cuc, &o.E, &o.Cus, &o.SqrtA,
+		&o.Toe, &o.Cic, &o.Omega0, &o.Cis,
+		&o.I0, &o.Crc, &o.Omega, &o.OmegaDot,
+		&o.IDOT, &e.DeltaNDot, &e.URAINED0, &e.URAINED1,
+		&e.URAIED, &e.Health, &e.TGD, &e.URAINED2,
+		&e.ISCL1CA, &e.ISCL2C, &e.ISCL5I5, &e.ISCL5Q5,
+		&e.TransmissionTime, &e.WeekOfPrediction, &e.Flags,
+	}
+}
+
+// CNV2Ephemeris is a GPS or QZSS CNAV-2 (L1C) ephemeris
+type CNV2Ephemeris struct {
+	rinex3.EphemerisHeader
+	ClockBias      float64
+	ClockDrift     float64
+	ClockDriftRate float64
+	ADot           float64
+	rinex3.KeplerianOrbit
+	DeltaNDot        float64
+	URAINED0         float64
+	URAINED1         float64
+	URAIED           float64
+	Health           float64
+	TGD              float64
+	URAINED2         float64
+	ISCL1CD          float64
+	ISCL1CP          float64
+	TransmissionTime float64
+	WeekOfPrediction float64
+	Flags            float64
+}
+
+func (e *CNV2Ephemeris) Fields() []*float64 {
+	o := &e.KeplerianOrbit
+	return []*float64{
+		&e.ClockBias, &e.ClockDrift, &e.ClockDriftRate,
+		&e.ADot, &o.Crs, &o.DeltaN, &o.M0,
+		&o.Cuc, &o.E, &o.Cus, &o.SqrtA,
+		&o.Toe, &o.Cic, &o.Omega0, &o.Cis,
+		&o.I0, &o.Crc, &o.Omega, &o.OmegaDot,
+		&o.IDOT, &e.DeltaNDot, &e.URAINED0, &e.URAINED1,
+		&e.URAIED, &e.Health, &e.TGD, &e.URAINED2,
+		&e.ISCL1CD, &e.ISCL1CP, nil, nil,
+		&e.TransmissionTime, &e.WeekOfPrediction, &e.Flags,
+	}
+}
+
+// BeiDouCNAVEphemeris is a BeiDou B-CNAV1 (CNV1) or B-CNAV2 (CNV2)
+// ephemeris, where ISCB1Cd is only given for CNV1 and ISCB2ad for CNV2, the
+// other being a spare field
+type BeiDouCNAVEphemeris struct {
+	rinex3.EphemerisHeader
+	Message        string // CNV1 or CNV2
+	ClockBias      float64
+	ClockDrift     float64
+	ClockDriftRate float64
+	ADot           float64
+	rinex3.KeplerianOrbit
+	DeltaNDot        float64
+	SatelliteType    float64
+	Top              float64
+	SISAIoe          float64
+	SISAIocb         float64
+	SISAIoc1         float64
+	SISAIoc2         float64
+	ISCB1Cd          float64
+	ISCB2ad          float64
+	TGDB1Cp          float64
+	TGDB2ap          float64
+	SISMAI           float64
+	Health           float64
+	IntegrityFlags   float64
+	IODC             float64
+	TransmissionTime float64
+	IODE             float64
+}
+
+func (e *BeiDouCNAVEphemeris) Fields() []*float64 {
+	o := &e.KeplerianOrbit
+	iscB1Cd, iscB2ad := &e.ISCB1Cd, &e.ISCB2ad
+	switch e.Message {
+	case "CNV1":
+		iscB2ad = nil
+	case "CNV2":
+		iscB1Cd = nil
+	}
+	return []*float64{
+		&e.ClockBias, &e.ClockDrift, &e.ClockDriftRate,
+		&e.ADot, &o.Crs, &o.DeltaN, &o.M0,
+		&o.Cuc, &o.E, &o.Cus, &o.SqrtA,
+		&o.Toe, &o.Cic, &o.Omega0, &o.Cis,
+		&o.I0, &o.Crc, &o.Omega, &o.OmegaDot,
+		&o.IDOT, &e.DeltaNDot, &e.SatelliteType, &e.Top,
+		&e.SISAIoe, &e.SISAIocb, &e.SISAIoc1, &e.SISAIoc2,
+		iscB1Cd, iscB2ad, &e.TGDB1Cp, &e.TGDB2ap,
+		&e.SISMAI, &e.Health, &e.IntegrityFlags, &e.IODC,
+		&e.TransmissionTime, nil, nil, &e.IODE,
+	}
+}
+
+// BeiDouCNV3Ephemeris is a BeiDou B-CNAV3 (B2b) ephemeris
+type BeiDouCNV3Ephemeris struct {
+	rinex3.EphemerisHeader
+	ClockBias      float64
+	ClockDrift     float64
+	ClockDriftRate float64
+	ADot           float64
+	rinex3.KeplerianOrbit
+	DeltaNDot        float64
+	SatelliteType    float64
+	Top              float64
+	SISAIoe          float64
+	SISAIocb         float64
+	SISAIoc1         float64
+	SISAIoc2         float64
+	SISMAI           float64
+	Health           float64
+	IntegrityFlags   float64
+	TGDB2bI          float64
+	TransmissionTime float64
+}
+
+func (e *BeiDouCNV3Ephemeris) Fields() []*float64 {
+	o := &e.KeplerianOrbit
+	return []*float64{
+		&e.ClockBias, &e.ClockDrift, &e.ClockDriftRate,
+		&e.ADot, &o.Crs, &o.DeltaN, &o.M0,
+		&o.Cuc, &o.E, &o.Cus, &o.SqrtA,
+		&o.Toe, &o.Cic, &o.Omega0, &o.Cis,
+		&o.I0, &o.Crc, &o.Omega, &o.OmegaDot,
+		&o.IDOT, &e.DeltaNDot, &e.SatelliteType, &e.Top,
+		&e.SISAIoe, &e.SISAIocb, &e.SISAIoc1, &e.SISAIoc2,
+		&e.SISMAI, &e.Health, &e.IntegrityFlags, &e.TGDB2bI,
+		&e.TransmissionTime,
+	}
+}
+
+// NewEphemerisData returns an empty ephemeris of the type used for the given
+// satellite system and navigation message, reusing the RINEX 3 types for
+// messages which have the same layout in both versions
+func NewEphemerisData(system, message string, eh rinex3.EphemerisHeader) (rinex3.NavigationRecord, error) {
+	switch system + " " + message {
+	case "G LNAV", "J LNAV", "E INAV", "E FNAV", "R FDMA", "C D1", "C D2", "I LNAV", "S SBAS":
+		return rinex3.NewNavigationRecord(system, eh)
+	case "G CNAV", "J CNAV":
+		return &CNAVEphemeris{EphemerisHeader: eh}, nil
+	case "G CNV2", "J CNV2":
+		return &CNV2Ephemeris{EphemerisHeader: eh}, nil
+	case "C CNV1", "C CNV2":
+		return &BeiDouCNAVEphemeris{EphemerisHeader: eh, Message: message}, nil
+	case "C CNV3":
+		return &BeiDouCNV3Ephemeris{EphemerisHeader: eh}, nil
+	default:
+		return nil, fmt.Errorf("invalid navigation message type \"%s\" for satellite system \"%s\"", message, system)
+	}
+}
+
+// CNAV ephemerides have no issue of data, being identified by their Time of
+// Clock and time of prediction
+func (e *CNAVEphemeris) IssueOfData() []float64 {
+	return nil
+}
+
+func (e *CNAVEphemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
+
+func (e *CNV2Ephemeris) IssueOfData() []float64 {
+	return nil
+}
+
+func (e *CNV2Ephemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
+
+func (e *BeiDouCNAVEphemeris) IssueOfData() []float64 {
+	return []float64{e.IODE, e.IODC}
+}
+
+func (e *BeiDouCNAVEphemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
+
+func (e *BeiDouCNV3Ephemeris) IssueOfData() []float64 {
+	return nil
+}
+
+func (e *BeiDouCNV3Ephemeris) TransmissionTimeField() *float64 {
+	return &e.TransmissionTime
+}
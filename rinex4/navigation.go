@@ -0,0 +1,264 @@
+package rinex4
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-gnss/rinex/rinex3"
+	"github.com/go-gnss/rinex/scanner"
+)
+
+// RecordHeader is the "> EPH G01 LNAV" line which starts every RINEX 4
+// navigation data record
+type RecordHeader struct {
+	Type      string // EPH, STO, EOP or ION
+	Satellite string
+	Message   string // e.g. LNAV, CNAV, INAV, FDMA, D1, CNVX
+}
+
+func (rh RecordHeader) SatelliteID() string {
+	return rh.Satellite
+}
+
+// Ephemeris is an EPH record, where Data is the typed ephemeris for the
+// satellite system and message, such as *rinex3.GPSEphemeris for GPS LNAV or
+// *CNAVEphemeris for GPS CNAV
+type Ephemeris struct {
+	RecordHeader
+	Data rinex3.NavigationRecord
+}
+
+func (e *Ephemeris) Epoch() time.Time {
+	return e.Data.Epoch()
+}
+
+func (e *Ephemeris) Fields() []*float64 {
+	return e.Data.Fields()
+}
+
+// Ephemeris implements rinex3.Ephemeris for merging, where every ephemeris
+// type returned by NewEphemerisData is itself a rinex3.Ephemeris
+
+func (e *Ephemeris) TimeOfEphemeris() float64 {
+	return e.Data.(rinex3.Ephemeris).TimeOfEphemeris()
+}
+
+func (e *Ephemeris) IssueOfData() []float64 {
+	return e.Data.(rinex3.Ephemeris).IssueOfData()
+}
+
+func (e *Ephemeris) TransmissionTimeField() *float64 {
+	return e.Data.(rinex3.Ephemeris).TransmissionTimeField()
+}
+
+// SystemTimeOffset is a STO record, where the offset between two time
+// systems is A0 + A1 * (t - t_ref) + A2 * (t - t_ref)^2, with t_ref being the
+// record's Time
+type SystemTimeOffset struct {
+	RecordHeader
+	Time             time.Time
+	OffsetType       string // e.g. GPUT, GAGP, BDUT
+	SBASIdentifier   string
+	UTCIdentifier    string
+	TransmissionTime float64
+	A0               float64
+	A1               float64
+	A2               float64
+}
+
+func (r *SystemTimeOffset) Epoch() time.Time {
+	return r.Time
+}
+
+// The first line of a STO record contains only text, so the first three
+// fields are placeholders
+func (r *SystemTimeOffset) Fields() []*float64 {
+	return []*float64{nil, nil, nil, &r.TransmissionTime, &r.A0, &r.A1, &r.A2}
+}
+
+// EarthOrientation is an EOP record, with pole coordinates in arc seconds
+// and UT1-UTC in seconds along with their first and second derivatives
+type EarthOrientation struct {
+	RecordHeader
+	Time             time.Time
+	XP               float64
+	XPDot            float64
+	XPDotDot         float64
+	YP               float64
+	YPDot            float64
+	YPDotDot         float64
+	TransmissionTime float64
+	DUT1             float64
+	DUT1Dot          float64
+	DUT1DotDot       float64
+}
+
+func (r *EarthOrientation) Epoch() time.Time {
+	return r.Time
+}
+
+func (r *EarthOrientation) Fields() []*float64 {
+	return []*float64{
+		&r.XP, &r.XPDot, &r.XPDotDot,
+		nil, &r.YP, &r.YPDot, &r.YPDotDot,
+		&r.TransmissionTime, &r.DUT1, &r.DUT1Dot, &r.DUT1DotDot,
+	}
+}
+
+// IonosphereKlobuchar is an ION record for the GPS, QZSS, BeiDou D1/D2 and
+// IRNSS Klobuchar model
+type IonosphereKlobuchar struct {
+	RecordHeader
+	Time   time.Time
+	Alpha  [4]float64
+	Beta   [4]float64
+	Region float64
+}
+
+func (r *IonosphereKlobuchar) Epoch() time.Time {
+	return r.Time
+}
+
+func (r *IonosphereKlobuchar) Fields() []*float64 {
+	return []*float64{
+		&r.Alpha[0], &r.Alpha[1], &r.Alpha[2],
+		&r.Alpha[3], &r.Beta[0], &r.Beta[1], &r.Beta[2],
+		&r.Beta[3], &r.Region,
+	}
+}
+
+// IonosphereNeQuickG is an ION record for the Galileo NeQuick-G model
+type IonosphereNeQuickG struct {
+	RecordHeader
+	Time             time.Time
+	AI               [3]float64
+	DisturbanceFlags float64
+}
+
+func (r *IonosphereNeQuickG) Epoch() time.Time {
+	return r.Time
+}
+
+func (r *IonosphereNeQuickG) Fields() []*float64 {
+	return []*float64{&r.AI[0], &r.AI[1], &r.AI[2], &r.DisturbanceFlags}
+}
+
+// IonosphereBDGIM is an ION record for the BeiDou global ionospheric model
+type IonosphereBDGIM struct {
+	RecordHeader
+	Time  time.Time
+	Alpha [9]float64
+}
+
+func (r *IonosphereBDGIM) Epoch() time.Time {
+	return r.Time
+}
+
+func (r *IonosphereBDGIM) Fields() []*float64 {
+	a := &r.Alpha
+	return []*float64{
+		&a[0], &a[1], &a[2],
+		&a[3], &a[4], &a[5], &a[6],
+		&a[7], &a[8],
+	}
+}
+
+// ParseNavigationRecord parses the next navigation data record, which is one
+// of *Ephemeris, *SystemTimeOffset, *EarthOrientation, *IonosphereKlobuchar,
+// *IonosphereNeQuickG or *IonosphereBDGIM. Records run until the next line
+// starting with ">", so unknown message types are reported without losing
+// synchronisation with the rest of the file.
+func ParseNavigationRecord(s *scanner.Scanner) (record rinex3.NavigationRecord, err error) {
+	line, err := s.ReadLine()
+	for err == nil && strings.TrimSpace(line) == "" {
+		line, err = s.ReadLine()
+	}
+	if err != nil {
+		return record, err
+	}
+
+	if len(line) < 10 || line[:2] != "> " {
+		return record, fmt.Errorf("invalid navigation record header at line %d", s.Line)
+	}
+	rh := RecordHeader{
+		Type:      line[2:5],
+		Satellite: strings.Replace(line[6:9], " ", "0", -1),
+	}
+	if len(line) > 10 {
+		rh.Message = strings.TrimSpace(line[10:])
+	}
+
+	var lines []string
+	for {
+		next, err := s.Peek(1)
+		if err != nil || next[0] == '>' {
+			break
+		}
+		if line, err = s.ReadLine(); err != nil {
+			return record, err
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, scanner.PadRight(line, 80))
+		}
+	}
+	if len(lines) == 0 {
+		return record, fmt.Errorf("empty %s record at line %d", rh.Type, s.Line)
+	}
+
+	t, err := rinex3.ParseNavigationEpoch(lines[0][4:23])
+	if err != nil {
+		return record, fmt.Errorf("invalid %s record epoch at line %d: %v", rh.Type, s.Line, err)
+	}
+
+	system := rh.Satellite[:1]
+	switch rh.Type {
+	case "EPH":
+		eh := rinex3.EphemerisHeader{Satellite: rh.Satellite, TimeOfClock: t}
+		data, err := NewEphemerisData(system, rh.Message, eh)
+		if err != nil {
+			return record, fmt.Errorf("%v at line %d", err, s.Line)
+		}
+		record = &Ephemeris{RecordHeader: rh, Data: data}
+	case "STO":
+		sto := &SystemTimeOffset{RecordHeader: rh, Time: t}
+		sto.OffsetType = strings.TrimSpace(lines[0][24:42])
+		sto.SBASIdentifier = strings.TrimSpace(lines[0][42:60])
+		sto.UTCIdentifier = strings.TrimSpace(lines[0][60:78])
+		record = sto
+	case "EOP":
+		record = &EarthOrientation{RecordHeader: rh, Time: t}
+	case "ION":
+		switch {
+		case system == "E":
+			record = &IonosphereNeQuickG{RecordHeader: rh, Time: t}
+		case system == "C" && rh.Message == "CNVX":
+			record = &IonosphereBDGIM{RecordHeader: rh, Time: t}
+		default:
+			record = &IonosphereKlobuchar{RecordHeader: rh, Time: t}
+		}
+	default:
+		return record, fmt.Errorf("invalid navigation record type \"%s\" at line %d", rh.Type, s.Line)
+	}
+
+	values := []string{lines[0][23:42], lines[0][42:61], lines[0][61:80]}
+	for _, line := range lines[1:] {
+		values = append(values, line[4:23], line[23:42], line[42:61], line[61:80])
+	}
+
+	fields := record.Fields()
+	if len(values) < len(fields)-4 {
+		return record, fmt.Errorf("incomplete %s record for %s at line %d", rh.Type, rh.Satellite, s.Line)
+	}
+
+	for i, field := range fields {
+		if field == nil || i >= len(values) {
+			continue
+		}
+		if *field, err = rinex3.ParseFortranFloat(values[i]); err != nil {
+			return record, fmt.Errorf("invalid %s record value at line %d: %v", rh.Type, s.Line, err)
+		}
+	}
+
+	return record, nil
+}
@@ -0,0 +1,85 @@
+package rinex4
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/rinex3"
+	"github.com/go-gnss/rinex/scanner"
+)
+
+// NavigationHeader is a RINEX 4 navigation header, in which the ionospheric
+// and time system corrections of RINEX 3 have moved into the data section as
+// ION and STO records
+type NavigationHeader struct {
+	header.Header
+	LeapSeconds        rinex3.LeapSeconds
+	MergedFiles        int
+	DOI                string
+	Licenses           []string
+	StationInformation []string
+}
+
+type NavigationHeaderRecordParser func(*scanner.Scanner, *NavigationHeader, header.HeaderRecord) error
+
+var (
+	NavigationHeaderRecordParsers map[string]NavigationHeaderRecordParser = map[string]NavigationHeaderRecordParser{
+		"LEAP SECONDS": func(_ *scanner.Scanner, h *NavigationHeader, hr header.HeaderRecord) (err error) {
+			h.LeapSeconds, err = rinex3.ParseLeapSeconds(hr.Value)
+			return err
+		},
+		"MERGED FILE": func(_ *scanner.Scanner, h *NavigationHeader, hr header.HeaderRecord) (err error) {
+			h.MergedFiles, err = strconv.Atoi(strings.TrimSpace(hr.Value[:9]))
+			return err
+		},
+		"DOI": func(_ *scanner.Scanner, h *NavigationHeader, hr header.HeaderRecord) error {
+			h.DOI = strings.TrimSpace(hr.Value)
+			return nil
+		},
+		"LICENSE OF USE": func(_ *scanner.Scanner, h *NavigationHeader, hr header.HeaderRecord) error {
+			h.Licenses = append(h.Licenses, strings.TrimSpace(hr.Value))
+			return nil
+		},
+		"STATION INFORMATION": func(_ *scanner.Scanner, h *NavigationHeader, hr header.HeaderRecord) error {
+			h.StationInformation = append(h.StationInformation, strings.TrimSpace(hr.Value))
+			return nil
+		},
+	}
+)
+
+func NewNavigationHeader(header header.Header) NavigationHeader {
+	return NavigationHeader{Header: header}
+}
+
+func ParseNavigationHeader(scanner *scanner.Scanner, header *NavigationHeader) error {
+	for {
+		hr, err := ParseNavigationHeaderRecord(scanner, header)
+		if err != nil {
+			return err
+		}
+		if hr.Key == "END OF HEADER" {
+			return nil
+		}
+	}
+}
+
+// ParseNavigationHeaderRecord parses the next header record into navHeader,
+// skipping any records which aren't defined for navigation files.
+func ParseNavigationHeaderRecord(scanner *scanner.Scanner, navHeader *NavigationHeader) (hr header.HeaderRecord, err error) {
+	hr, err = header.ParseHeaderRecord(scanner)
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+
+	if parser, ok := header.HeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, &navHeader.Header, hr)
+	} else if parser, ok := NavigationHeaderRecordParsers[hr.Key]; ok {
+		err = parser(scanner, navHeader, hr)
+	}
+
+	if err != nil {
+		return hr, header.NewHeaderRecordParsingError(err, scanner.Line)
+	}
+	return hr, nil
+}
@@ -0,0 +1,127 @@
+package rinex4
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-gnss/rinex/header"
+	"github.com/go-gnss/rinex/rinex3"
+)
+
+// NavigationWriter writes a RINEX 4 navigation file to an io.Writer one
+// record at a time.
+type NavigationWriter struct {
+	w      io.Writer
+	Header NavigationHeader
+}
+
+// NewNavigationWriter writes the header records of h to w, and returns a
+// NavigationWriter for writing the records which follow.
+func NewNavigationWriter(w io.Writer, h NavigationHeader) (*NavigationWriter, error) {
+	writer := &NavigationWriter{w, h}
+	_, err := io.WriteString(w, strings.Join(FormatNavigationHeader(h), ""))
+	return writer, err
+}
+
+// WriteRecord writes a RINEX 4 navigation data record, or a RINEX 3
+// ephemeris as an EPH record.
+func (w *NavigationWriter) WriteRecord(record rinex3.NavigationRecord) error {
+	text, err := FormatNavigationRecord(record)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w.w, text)
+	return err
+}
+
+// FormatNavigationHeader formats the records of a NavigationHeader as lines.
+func FormatNavigationHeader(h NavigationHeader) (lines []string) {
+	lines = rinex3.FormatHeader(h.Header, "N: GNSS NAV DATA")
+
+	if h.MergedFiles != 0 {
+		lines = append(lines, header.FormatHeaderRecord(fmt.Sprintf("%9d", h.MergedFiles), "MERGED FILE"))
+	}
+	if h.DOI != "" {
+		lines = append(lines, header.FormatHeaderRecord(h.DOI, "DOI"))
+	}
+	for _, license := range h.Licenses {
+		lines = append(lines, header.FormatHeaderRecord(license, "LICENSE OF USE"))
+	}
+	for _, station := range h.StationInformation {
+		lines = append(lines, header.FormatHeaderRecord(station, "STATION INFORMATION"))
+	}
+	if h.LeapSeconds.Current != 0 {
+		lines = append(lines, header.FormatHeaderRecord(rinex3.FormatLeapSeconds(h.LeapSeconds), "LEAP SECONDS"))
+	}
+
+	return append(lines, header.FormatHeaderRecord("", "END OF HEADER"))
+}
+
+// FormatNavigationRecord formats a navigation data record as its "> EPH G01
+// LNAV" style record header followed by its data lines, with values in
+// E19.12. RINEX 3 ephemerides are written as EPH records of the legacy
+// navigation message for their satellite system.
+func FormatNavigationRecord(record rinex3.NavigationRecord) (string, error) {
+	var rh RecordHeader
+	prefix := "    " + rinex3.FormatNavigationEpoch(record.Epoch())
+	fields := record.Fields()
+
+	switch r := record.(type) {
+	case *Ephemeris:
+		rh = r.RecordHeader
+		prefix = r.Satellite + " " + rinex3.FormatNavigationEpoch(record.Epoch())
+	case *SystemTimeOffset:
+		rh = r.RecordHeader
+		// The text fields replace the spare first three values
+		prefix += fmt.Sprintf(" %-18.18s%-18.18s%-18.18s", r.OffsetType, r.SBASIdentifier, r.UTCIdentifier)
+	case *EarthOrientation:
+		rh = r.RecordHeader
+	case *IonosphereKlobuchar:
+		rh = r.RecordHeader
+	case *IonosphereNeQuickG:
+		rh = r.RecordHeader
+	case *IonosphereBDGIM:
+		rh = r.RecordHeader
+	default:
+		message, err := LegacyMessage(record)
+		if err != nil {
+			return "", err
+		}
+		rh = RecordHeader{Type: "EPH", Satellite: record.SatelliteID(), Message: message}
+		prefix = rh.Satellite + " " + rinex3.FormatNavigationEpoch(record.Epoch())
+	}
+
+	return formatRecordHeader(rh) + rinex3.FormatNavigationLines(prefix, fields, "E"), nil
+}
+
+func formatRecordHeader(rh RecordHeader) string {
+	return strings.TrimRight(fmt.Sprintf("> %-3.3s %-3.3s %s", rh.Type, rh.Satellite, rh.Message), " ") + "\n"
+}
+
+// LegacyMessage returns the navigation message type of a RINEX 3 ephemeris,
+// where Galileo F/NAV is identified by its data sources and BeiDou D2 by the
+// PRNs of the GEO satellites.
+func LegacyMessage(record rinex3.NavigationRecord) (string, error) {
+	switch r := record.(type) {
+	case *rinex3.GPSEphemeris, *rinex3.QZSSEphemeris, *rinex3.IRNSSEphemeris:
+		return "LNAV", nil
+	case *rinex3.GalileoEphemeris:
+		if int(r.DataSources)&(1<<1) != 0 {
+			return "FNAV", nil
+		}
+		return "INAV", nil
+	case *rinex3.GLONASSEphemeris:
+		return "FDMA", nil
+	case *rinex3.BeiDouEphemeris:
+		if prn, err := strconv.Atoi(r.Satellite[1:]); err == nil && (prn <= 5 || prn >= 59) {
+			return "D2", nil
+		}
+		return "D1", nil
+	case *rinex3.SBASEphemeris:
+		return "SBAS", nil
+	default:
+		return "", fmt.Errorf("%s record of type %T can't be written to a RINEX 4 file", record.SatelliteID(), record)
+	}
+}
@@ -1,13 +1,44 @@
 package scanner
 
-import "bufio"
+import (
+	"bufio"
+	"io"
+	"strings"
+)
 
 type Scanner struct {
 	*bufio.Reader
 	Line int
+	// Recorded holds the lines read while Record is set, such as the raw
+	// lines of a header
+	Record   bool
+	Recorded []string
 }
 
+// ReadLine returns the next line without its line terminator. A final line
+// which is not terminated by a newline is returned without error, with io.EOF
+// being returned by the following call.
 func (s *Scanner) ReadLine() (line string, err error) {
+	line, err = s.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	if err != nil {
+		return line, err
+	}
 	s.Line += 1
-	return s.ReadString('\n')
+	line = strings.TrimRight(line, "\r\n")
+	if s.Record {
+		s.Recorded = append(s.Recorded, line)
+	}
+	return line, nil
+}
+
+// PadRight pads a line with spaces to a length, so that the fixed columns of
+// a line whose trailing blanks were removed can be sliced.
+func PadRight(line string, length int) string {
+	if len(line) >= length {
+		return line
+	}
+	return line + strings.Repeat(" ", length-len(line))
 }